@@ -51,12 +51,6 @@ check_prerequisites() {
     fi
     log_info "Docker environment check completed"
     
-    # Check wrench
-    if ! command -v wrench > /dev/null 2>&1; then
-        log_error "wrench not found. Install with: go install github.com/cloudspannerecosystem/wrench@latest"
-        exit 1
-    fi
-    
     # Check Node.js
     if ! command -v node > /dev/null 2>&1; then
         log_error "Node.js not found"
@@ -252,9 +246,8 @@ show_summary() {
         echo ""
         log_info "Troubleshooting:"
         log_info "  1. Ensure Docker Desktop is running"
-        log_info "  2. Ensure wrench is installed"
-        log_info "  3. Ensure ports 9010, 9020 are not in use"
-        log_info "  4. Check schema files in /tmp/ci-schemas/"
+        log_info "  2. Ensure ports 9010, 9020 are not in use"
+        log_info "  3. Check schema files in /tmp/ci-schemas/"
         echo ""
         log_info "Manual testing:"
         log_info "  npx ts-node scripts/setup-ci-schemas.ts"
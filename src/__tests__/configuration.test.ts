@@ -393,6 +393,7 @@ describe('Configuration Module', () => {
       const result = generateEnvironmentContent(config);
 
       expect(result).toContain('DB_COUNT=1');
+      expect(result).toContain('DATABASES=primary\n');
       expect(result).toContain('PRIMARY_DB_ID=test-primary');
       expect(result).toContain('PRIMARY_DATABASE_ID=test-primary');
       expect(result).toContain('PRIMARY_DB_SCHEMA_PATH=/path/to/primary/schema');
@@ -428,6 +429,7 @@ describe('Configuration Module', () => {
       const result = generateEnvironmentContent(config);
 
       expect(result).toContain('DB_COUNT=2');
+      expect(result).toContain('DATABASES=primary,secondary');
       expect(result).toContain('PRIMARY_DB_ID=test-primary');
       expect(result).toContain('PRIMARY_DATABASE_ID=test-primary');
       expect(result).toContain('PRIMARY_DB_SCHEMA_PATH=/path/to/primary/schema');
@@ -134,6 +134,7 @@ export function generateEnvironmentContent(config: DatabaseConfig): string {
 
 # 🔧 Database Settings
 DB_COUNT=${config.count}
+DATABASES=${config.count === '2' ? 'primary,secondary' : 'primary'}
 PRIMARY_DB_ID=${config.primaryDbName}
 PRIMARY_DATABASE_ID=${config.primaryDbName}
 PRIMARY_DB_SCHEMA_PATH=${config.primarySchemaPath}
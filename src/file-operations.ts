@@ -237,7 +237,7 @@ export function processTemplateFiles(projectPath: string, projectName: string):
     });
   }
 
-  // Copy spalidate validation templates
+  // Copy expected-state validation templates
   const validationTemplateFiles = [
     'expected-primary.yaml.template',
     'expected-secondary.yaml.template',
@@ -2,68 +2,120 @@ package main
 
 import (
 	"context"
-	"database/sql"
 	"flag"
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
 
 	"PROJECT_NAME/internal/spanwright"
-	"github.com/go-testfixtures/testfixtures/v3"
-	_ "github.com/googleapis/go-sql-spanner"
 )
 
 func main() {
 	// Parse command-line flags
-	var databaseID = flag.String("database-id", "", "Database ID to inject seed data")
+	var databaseID = flag.String("database-id", "", "Logical name or ID of the database to inject seed data")
 	var fixtureDir = flag.String("fixture-dir", "", "Path to fixture directory containing YAML files")
+	var scenarioDir = flag.String("scenario-dir", "", "Scenario directory; seeds every configured database from its fixtures sub-directory")
+	var scenario = flag.String("scenario", "", "Scenario name, resolved under the configured scenarios directory")
+	var seededDir = flag.String("seeded-dir", spanwright.DefaultSeededDir, "Directory to record the keys of seeded rows in, for spanwright reset --mode seeded")
+	configFlags := spanwright.BindConfigFlags(flag.CommandLine)
 	flag.Parse()
 
-	if *databaseID == "" || *fixtureDir == "" {
-		log.Fatal("Both --database-id and --fixture-dir are required")
+	if *scenario != "" && *scenarioDir != "" {
+		log.Fatal("--scenario and --scenario-dir cannot be combined")
 	}
 
 	// Load configuration
-	config, err := spanwright.LoadConfig()
+	config, err := spanwright.LoadConfigWithOptions(configFlags.Options())
 	if err != nil {
 		log.Fatalf("Configuration error: %v", err)
 	}
 
+	if *scenario != "" {
+		*scenarioDir = config.ScenarioDir(*scenario)
+	}
+	if *scenarioDir == "" && (*databaseID == "" || *fixtureDir == "") {
+		log.Fatal("Either --scenario, --scenario-dir or both --database-id and --fixture-dir are required")
+	}
+	if *scenarioDir != "" && *fixtureDir != "" {
+		log.Fatal("--scenario-dir and --fixture-dir cannot be combined")
+	}
+
+	// Refuse to seed anything but the emulator
+	if err := config.EmulatorGuard().CheckEnvironment(); err != nil {
+		log.Fatalf("🚨 %v", err)
+	}
+
+	// Cancel in-flight work on SIGINT/SIGTERM and bound the whole run by TIMEOUT_SECONDS
+	signalCtx, stop := spanwright.SignalContext(context.Background())
+	ctx, cancel := config.WithTimeout(signalCtx)
+
 	// Execute seed injection
-	log.Printf("Injecting seed data into %s/%s/%s", config.ProjectID, config.InstanceID, *databaseID)
-	log.Printf("Loading fixtures from: %s", *fixtureDir)
+	if *scenarioDir != "" {
+		err = injectScenario(ctx, config, *scenarioDir, *databaseID, *seededDir)
+	} else {
+		err = injectSeedData(ctx, config, *databaseID, *fixtureDir, *seededDir)
+	}
+	cancel()
+	stop()
 
-	if err := injectSeedData(config.ProjectID, config.InstanceID, *databaseID, *fixtureDir); err != nil {
-		log.Fatalf("Seed injection failed: %v", err)
+	if err != nil {
+		code := spanwright.ExitCode(signalCtx, err)
+		switch code {
+		case spanwright.ExitInterrupted:
+			log.Printf("🚨 Seed injection interrupted: %v", err)
+		case spanwright.ExitTimeout:
+			log.Printf("🚨 Seed injection timed out after %v: %v", config.TimeoutDuration(), err)
+		default:
+			log.Printf("Seed injection failed: %v", err)
+		}
+		os.Exit(code)
 	}
 
 	log.Println("✅ Seed data injection completed successfully")
 }
 
-func injectSeedData(projectID, instanceID, databaseID, fixtureDir string) error {
-	ctx := context.Background()
-	
-	// Build database connection string
-	dsn, err := spanwright.BuildDSN(projectID, instanceID, databaseID)
+// injectScenario seeds every configured database, or only the selected one, from the
+// fixture sub-directories of a scenario. Databases without fixtures are skipped.
+func injectScenario(ctx context.Context, config *spanwright.Config, scenarioDir, database, seededDir string) error {
+	databases := config.Databases
+	if database != "" {
+		spec, err := config.Database(database)
+		if err != nil {
+			return err
+		}
+		databases = []spanwright.DatabaseSpec{*spec}
+	}
+
+	for _, spec := range databases {
+		fixtureDir := spec.FixturePath(scenarioDir)
+		if _, err := os.Stat(fixtureDir); os.IsNotExist(err) {
+			log.Printf("⚠️ No fixtures for %s in %s, skipping", spec.Name, scenarioDir)
+			continue
+		}
+
+		if err := injectSeedData(ctx, config, spec.Name, fixtureDir, seededDir); err != nil {
+			return fmt.Errorf("%s: %w", spec.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func injectSeedData(ctx context.Context, config *spanwright.Config, database, fixtureDir, seededDir string) error {
+	spec, err := config.Database(database)
 	if err != nil {
-		return fmt.Errorf("failed to build DSN: %v", err)
+		return err
 	}
-	
-	// Open database connection
-	db, err := sql.Open("spanner", dsn)
+	dbConfig, err := config.GetDatabaseConfig(database)
 	if err != nil {
-		return fmt.Errorf("failed to open database: %v", err)
+		return err
 	}
-	defer db.Close()
 
-	// Test connection
-	if err := db.PingContext(ctx); err != nil {
-		return fmt.Errorf("failed to connect to database: %v", err)
-	}
+	log.Printf("Injecting seed data into %s/%s/%s", config.ProjectID, config.InstanceID, dbConfig.DatabaseID)
+	log.Printf("Loading fixtures from: %s", fixtureDir)
 
 	// Get fixture files
-	fixtureFiles, err := getFixtureFiles(fixtureDir)
+	fixtureFiles, err := spanwright.FixtureFiles(fixtureDir)
 	if err != nil {
 		return fmt.Errorf("failed to get fixture files: %v", err)
 	}
@@ -72,42 +124,23 @@ func injectSeedData(projectID, instanceID, databaseID, fixtureDir string) error
 		return fmt.Errorf("no fixture files found in %s", fixtureDir)
 	}
 
-	// Create testfixtures loader
-	fixtures, err := testfixtures.New(
-		testfixtures.Database(db),
-		testfixtures.Dialect("spanner"),
-		testfixtures.Files(fixtureFiles...),
-		testfixtures.DangerousSkipTestDatabaseCheck(),
-	)
+	// Connect through the shared database manager
+	dm, err := spanwright.NewDatabaseManager(ctx, dbConfig)
 	if err != nil {
-		return fmt.Errorf("failed to create fixtures loader: %v", err)
-	}
-
-	// Load fixtures
-	if err := fixtures.Load(); err != nil {
-		return fmt.Errorf("failed to load fixtures: %v", err)
+		return fmt.Errorf("failed to connect to database: %w", err)
 	}
+	defer dm.Close()
 
-	return nil
-}
-
-func getFixtureFiles(fixtureDir string) ([]string, error) {
-	// Check directory exists
-	if _, err := os.Stat(fixtureDir); err != nil {
-		return nil, fmt.Errorf("fixture directory not accessible: %v", err)
+	// Keep the keys recorded for tables seeded by earlier runs
+	seededPath := spec.SeededPath(seededDir)
+	if err := dm.LoadSeededRows(seededPath); err != nil {
+		return err
 	}
 
-	// Find all YAML files
-	var fixtureFiles []string
-	
-	patterns := []string{"*.yml", "*.yaml"}
-	for _, pattern := range patterns {
-		files, err := filepath.Glob(filepath.Join(fixtureDir, pattern))
-		if err != nil {
-			return nil, fmt.Errorf("failed to list files: %v", err)
-		}
-		fixtureFiles = append(fixtureFiles, files...)
+	// Load fixtures
+	if err := spanwright.NewSeeder(dm).SeedFiles(ctx, fixtureFiles); err != nil {
+		return fmt.Errorf("failed to load fixtures: %w", err)
 	}
 
-	return fixtureFiles, nil
-}
\ No newline at end of file
+	return dm.SaveSeededRows(seededPath)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"PROJECT_NAME/internal/spanwright"
+)
+
+// runConfig prints every resolved configuration value together with its source
+func runConfig(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("config", flag.ExitOnError)
+	configFlags := spanwright.BindConfigFlags(flags)
+	flags.Parse(args)
+
+	config, err := spanwright.LoadConfigWithOptions(configFlags.Options())
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+	for _, setting := range config.Settings() {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", setting.Key, setting.Value, setting.Source)
+	}
+	return w.Flush()
+}
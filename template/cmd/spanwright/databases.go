@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"PROJECT_NAME/internal/spanwright"
+)
+
+// runDatabases prints the configured database IDs, one per line, for use in shell loops
+func runDatabases(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("databases", flag.ExitOnError)
+	configFlags := spanwright.BindConfigFlags(flags)
+	flags.Parse(args)
+
+	config, err := spanwright.LoadConfigWithOptions(configFlags.Options())
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	for _, spec := range config.Databases {
+		fmt.Println(spec.DatabaseID)
+	}
+	return nil
+}
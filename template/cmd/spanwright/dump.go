@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"PROJECT_NAME/internal/spanwright"
+)
+
+// runDump writes the current contents of the databases as fixture YAML, either into a
+// scenario's fixture directories or under an output directory
+func runDump(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("dump", flag.ExitOnError)
+	database := flags.String("database", "", "Logical name or ID of the database (default: all configured databases)")
+	tables := flags.String("tables", "", "Comma-separated tables to dump (default: all tables)")
+	scenario := flags.String("scenario", "", "Scenario to write the fixtures of, e.g. example-02-captured")
+	out := flags.String("out", "", "Directory to write <fixture dir>/<table>.yaml files under, instead of --scenario")
+	configFlags := spanwright.BindConfigFlags(flags)
+	flags.Parse(args)
+
+	if (*scenario == "") == (*out == "") {
+		return fmt.Errorf("exactly one of --scenario or --out is required")
+	}
+	if *tables != "" && *database == "" {
+		return fmt.Errorf("--tables requires --database")
+	}
+
+	config, err := spanwright.LoadConfigWithOptions(configFlags.Options())
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	databases := config.Databases
+	if *database != "" {
+		spec, err := config.Database(*database)
+		if err != nil {
+			return err
+		}
+		databases = []spanwright.DatabaseSpec{*spec}
+	}
+
+	tableNames := splitList(*tables)
+
+	ctx, cancel := config.WithTimeout(ctx)
+	defer cancel()
+
+	for _, spec := range databases {
+		dir := filepath.Join(*out, spec.FixtureDir)
+		if *scenario != "" {
+			dir = spec.FixturePath(config.ScenarioDir(*scenario))
+		}
+
+		if err := dumpDatabase(ctx, config, spec, tableNames, dir); err != nil {
+			return fmt.Errorf("%s: %w", spec.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func dumpDatabase(ctx context.Context, config *spanwright.Config, spec spanwright.DatabaseSpec, tables []string, dir string) error {
+	dbConfig, err := config.GetDatabaseConfig(spec.Name)
+	if err != nil {
+		return err
+	}
+
+	dm, err := spanwright.NewDatabaseManager(ctx, dbConfig)
+	if err != nil {
+		return err
+	}
+	defer dm.Close()
+
+	if err := dm.Dump(ctx, tables, spanwright.DirFixtureWriter{Dir: dir}); err != nil {
+		return err
+	}
+
+	log.Printf("✅ Dumped %s to %s", spec.DatabaseID, dir)
+	return nil
+}
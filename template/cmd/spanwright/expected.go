@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"PROJECT_NAME/internal/spanwright"
+)
+
+// runExpected writes the current contents of the databases as expected-state files, either
+// into a scenario or, for a single database, to stdout
+func runExpected(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("expected", flag.ExitOnError)
+	database := flags.String("database", "", "Logical name or ID of the database (default: all configured databases)")
+	tables := flags.String("tables", "", "Comma-separated tables to include (default: all tables)")
+	scenario := flags.String("scenario", "", "Scenario to write the expected files of (default: print to stdout)")
+	skipColumns := flags.String("skip-columns", "", "Comma-separated volatile columns to leave out, as Column or Table.Column")
+	skipCommitTimestamps := flags.Bool("skip-commit-timestamps", false, "Leave out columns that allow commit timestamps")
+	perRow := flags.Bool("per-row", false, "Write every row under rows instead of only the first under columns")
+	configFlags := spanwright.BindConfigFlags(flags)
+	flags.Parse(args)
+
+	if *scenario == "" && *database == "" {
+		return fmt.Errorf("--database is required when printing to stdout")
+	}
+	if *tables != "" && *database == "" {
+		return fmt.Errorf("--tables requires --database")
+	}
+
+	config, err := spanwright.LoadConfigWithOptions(configFlags.Options())
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	databases := config.Databases
+	if *database != "" {
+		spec, err := config.Database(*database)
+		if err != nil {
+			return err
+		}
+		databases = []spanwright.DatabaseSpec{*spec}
+	}
+
+	opts := spanwright.ExpectedOptions{
+		Tables:               splitList(*tables),
+		SkipColumns:          splitList(*skipColumns),
+		SkipCommitTimestamps: *skipCommitTimestamps,
+		PerRow:               *perRow,
+	}
+
+	ctx, cancel := config.WithTimeout(ctx)
+	defer cancel()
+
+	for _, spec := range databases {
+		content, err := generateExpected(ctx, config, spec, opts)
+		if err != nil {
+			return fmt.Errorf("%s: %w", spec.Name, err)
+		}
+
+		if *scenario == "" {
+			os.Stdout.Write(content)
+			continue
+		}
+
+		path := spec.ExpectedPath(config.ScenarioDir(*scenario))
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			return fmt.Errorf("failed to write expected file %s: %w", path, err)
+		}
+		log.Printf("✅ Wrote expected state of %s to %s", spec.DatabaseID, path)
+	}
+
+	return nil
+}
+
+func generateExpected(ctx context.Context, config *spanwright.Config, spec spanwright.DatabaseSpec, opts spanwright.ExpectedOptions) ([]byte, error) {
+	dbConfig, err := config.GetDatabaseConfig(spec.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	dm, err := spanwright.NewDatabaseManager(ctx, dbConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer dm.Close()
+
+	return dm.GenerateExpected(ctx, opts)
+}
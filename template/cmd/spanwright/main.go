@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"PROJECT_NAME/internal/spanwright"
+)
+
+// command is a spanwright subcommand
+type command struct {
+	name        string
+	description string
+	run         func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{name: "apply-schema", description: "Give the databases their schema and no rows, rebuilding changed ones", run: runApplySchema},
+	{name: "dump", description: "Write the current database contents as fixture YAML", run: runDump},
+	{name: "expected", description: "Write the current database contents as expected-state YAML", run: runExpected},
+	{name: "workers", description: "Create seeded database clones for parallel test workers", run: runWorkers},
+	{name: "snapshot", description: "Save or restore the database contents as a named snapshot", run: runSnapshot},
+	{name: "reset", description: "Clear the databases: all rows, only seeded rows, or recreate them", run: runReset},
+	{name: "validate", description: "Check the databases against their expected-state YAML", run: runValidate},
+	{name: "databases", description: "Print the configured database IDs", run: runDatabases},
+	{name: "config", description: "Print the resolved configuration and where each value came from", run: runConfig},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		printUsage()
+		return
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == name {
+			// SIGINT/SIGTERM cancel in-flight work; each command bounds it by TIMEOUT_SECONDS
+			ctx, stop := spanwright.SignalContext(context.Background())
+			err := cmd.run(ctx, os.Args[2:])
+			stop()
+			if err != nil {
+				log.Printf("❌ %s failed: %v", name, err)
+				os.Exit(spanwright.ExitCode(ctx, err))
+			}
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", name)
+	printUsage()
+	os.Exit(2)
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: spanwright <command> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", cmd.name, cmd.description)
+	}
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"PROJECT_NAME/internal/spanwright"
+)
+
+// runReset clears the databases after a scenario, and with --workers the clones of each
+// parallel worker. Every database is reset even when an earlier one fails, and each failure
+// is reported.
+func runReset(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("reset", flag.ExitOnError)
+	modeName := flags.String("mode", string(spanwright.ResetAll), "How to clear the databases: all, seeded or recreate")
+	database := flags.String("database", "", "Logical name or ID of the database (default: all configured databases)")
+	seededDir := flags.String("seeded-dir", spanwright.DefaultSeededDir, "Directory the keys of seeded rows are recorded in")
+	workers := flags.Int("workers", 0, "Also reset the database clones of this many parallel workers")
+	configFlags := spanwright.BindConfigFlags(flags)
+	flags.Parse(args)
+
+	mode, err := spanwright.ParseResetMode(*modeName)
+	if err != nil {
+		return err
+	}
+
+	config, err := spanwright.LoadConfigWithOptions(configFlags.Options())
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	// Resolve --database to its logical name, which the worker clones share
+	name := ""
+	if *database != "" {
+		spec, err := config.Database(*database)
+		if err != nil {
+			return err
+		}
+		name = spec.Name
+	}
+
+	configs := []*spanwright.Config{config}
+	for worker := 0; worker < *workers; worker++ {
+		workerConfig, err := config.ForWorker(worker)
+		if err != nil {
+			return err
+		}
+		configs = append(configs, workerConfig)
+	}
+
+	ctx, cancel := config.WithTimeout(ctx)
+	defer cancel()
+
+	total, failed := 0, 0
+	for _, config := range configs {
+		databases := config.Databases
+		if name != "" {
+			spec, err := config.Database(name)
+			if err != nil {
+				return err
+			}
+			databases = []spanwright.DatabaseSpec{*spec}
+		}
+
+		for _, spec := range databases {
+			total++
+			if err := resetDatabase(ctx, config, spec, mode, spec.SeededPath(*seededDir)); err != nil {
+				log.Printf("❌ %s (%s): %v", spec.Name, spec.DatabaseID, err)
+				failed++
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d databases could not be reset", failed, total)
+	}
+	log.Printf("✅ Reset %d databases (mode %s)", total, mode)
+	return nil
+}
+
+func resetDatabase(ctx context.Context, config *spanwright.Config, spec spanwright.DatabaseSpec, mode spanwright.ResetMode, seededPath string) error {
+	dbConfig, err := config.GetDatabaseConfig(spec.Name)
+	if err != nil {
+		return err
+	}
+
+	dm, err := spanwright.NewDatabaseManager(ctx, dbConfig)
+	if err != nil {
+		return err
+	}
+	defer dm.Close()
+
+	if err := dm.LoadSeededRows(seededPath); err != nil {
+		return err
+	}
+	if err := dm.Reset(ctx, mode); err != nil {
+		return err
+	}
+	return dm.SaveSeededRows(seededPath)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"PROJECT_NAME/internal/spanwright"
+)
+
+func runApplySchema(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("apply-schema", flag.ExitOnError)
+	database := flags.String("database", "", "Logical name or ID of the database (default: all configured databases)")
+	schemaPath := flags.String("schema-path", "", "Directory containing *.sql schema files (default: the database's schema path)")
+	cacheFile := flags.String("schema-cache", spanwright.DefaultSchemaCacheFile, "File recording the schema each database was built with")
+	rebuild := flags.Bool("rebuild", false, "Drop and recreate existing databases even when their schema is unchanged")
+	configFlags := spanwright.BindConfigFlags(flags)
+	flags.Parse(args)
+
+	if *schemaPath != "" && *database == "" {
+		return fmt.Errorf("--schema-path requires --database")
+	}
+
+	config, err := spanwright.LoadConfigWithOptions(configFlags.Options())
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	databases := config.Databases
+	if *database != "" {
+		spec, err := config.Database(*database)
+		if err != nil {
+			return err
+		}
+		databases = []spanwright.DatabaseSpec{*spec}
+	}
+
+	cache, err := loadSchemaCache(*cacheFile, *rebuild)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := config.WithTimeout(ctx)
+	defer cancel()
+
+	for _, spec := range databases {
+		path := spec.SchemaPath
+		if *schemaPath != "" {
+			path = *schemaPath
+		}
+
+		if err := applySchema(ctx, config, spec, path, cache); err != nil {
+			return fmt.Errorf("%s: %w", spec.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// loadSchemaCache loads the schema cache, or returns nil so every existing database is
+// rebuilt
+func loadSchemaCache(path string, rebuild bool) (*spanwright.SchemaCache, error) {
+	if rebuild || path == "" {
+		log.Printf("⚠️ No schema cache in use: every existing database will be dropped and recreated")
+		return nil, nil
+	}
+	return spanwright.LoadSchemaCache(path)
+}
+
+// applySchema leaves a database with the schema and no rows, truncating it instead of
+// rebuilding it when the cache shows its schema is unchanged
+func applySchema(ctx context.Context, config *spanwright.Config, spec spanwright.DatabaseSpec, schemaPath string, cache *spanwright.SchemaCache) error {
+	dbConfig, err := config.GetDatabaseConfig(spec.Name)
+	if err != nil {
+		return err
+	}
+
+	dm, err := spanwright.NewDatabaseManager(ctx, dbConfig)
+	if err != nil {
+		return err
+	}
+	defer dm.Close()
+
+	log.Printf("Applying schema from %s to %s", schemaPath, spec.DatabaseID)
+	action, err := dm.PrepareSchema(ctx, schemaPath, cache)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case spanwright.SchemaTruncated:
+		log.Printf("✅ Schema of %s is unchanged; truncated its tables", spec.DatabaseID)
+	case spanwright.SchemaRebuilt:
+		log.Printf("✅ Schema changed; rebuilt %s", spec.DatabaseID)
+	default:
+		log.Printf("✅ Schema applied to %s", spec.DatabaseID)
+	}
+	return nil
+}
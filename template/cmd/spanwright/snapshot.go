@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"regexp"
+
+	"PROJECT_NAME/internal/spanwright"
+)
+
+// snapshotNameRegex keeps snapshot names usable as file names
+var snapshotNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// runSnapshot saves the contents of the databases under a name, to be used as a baseline
+// for validate --baseline, or restores a saved snapshot
+func runSnapshot(ctx context.Context, args []string) error {
+	if len(args) == 0 || (args[0] != "save" && args[0] != "restore") {
+		return fmt.Errorf("usage: spanwright snapshot save|restore <name> [flags]")
+	}
+	action := args[0]
+
+	flags := flag.NewFlagSet("snapshot "+action, flag.ExitOnError)
+	database := flags.String("database", "", "Logical name or ID of the database (default: all configured databases)")
+	dir := flags.String("dir", spanwright.DefaultSnapshotDir, "Directory the snapshots are kept in")
+	configFlags := spanwright.BindConfigFlags(flags)
+	name, err := parseNamed(flags, args[1:])
+	if err != nil {
+		return err
+	}
+
+	config, err := spanwright.LoadConfigWithOptions(configFlags.Options())
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	databases := config.Databases
+	if *database != "" {
+		spec, err := config.Database(*database)
+		if err != nil {
+			return err
+		}
+		databases = []spanwright.DatabaseSpec{*spec}
+	}
+
+	ctx, cancel := config.WithTimeout(ctx)
+	defer cancel()
+
+	for _, spec := range databases {
+		path := spec.SnapshotPath(*dir, name)
+		if action == "restore" {
+			if err := restoreSnapshot(ctx, config, spec, path); err != nil {
+				return fmt.Errorf("%s: %w", spec.Name, err)
+			}
+			continue
+		}
+
+		if err := saveSnapshot(ctx, config, spec, path); err != nil {
+			return fmt.Errorf("%s: %w", spec.Name, err)
+		}
+		log.Printf("✅ Saved snapshot %s of %s to %s", name, spec.DatabaseID, path)
+	}
+	return nil
+}
+
+func saveSnapshot(ctx context.Context, config *spanwright.Config, spec spanwright.DatabaseSpec, path string) error {
+	dbConfig, err := config.GetDatabaseConfig(spec.Name)
+	if err != nil {
+		return err
+	}
+
+	dm, err := spanwright.NewDatabaseManager(ctx, dbConfig)
+	if err != nil {
+		return err
+	}
+	defer dm.Close()
+
+	snapshot, err := dm.Snapshot(ctx)
+	if err != nil {
+		return err
+	}
+	return snapshot.WriteFile(path)
+}
+
+func restoreSnapshot(ctx context.Context, config *spanwright.Config, spec spanwright.DatabaseSpec, path string) error {
+	// Read the file first so a missing snapshot leaves the database untouched
+	snapshot, err := spanwright.LoadSnapshotFile(path)
+	if err != nil {
+		return err
+	}
+
+	dbConfig, err := config.GetDatabaseConfig(spec.Name)
+	if err != nil {
+		return err
+	}
+
+	dm, err := spanwright.NewDatabaseManager(ctx, dbConfig)
+	if err != nil {
+		return err
+	}
+	defer dm.Close()
+
+	return dm.Restore(ctx, snapshot)
+}
+
+// parseNamed parses flags around a single positional name, so both "save base --database
+// primary" and "save --database primary base" work
+func parseNamed(flags *flag.FlagSet, args []string) (string, error) {
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		return "", fmt.Errorf("a snapshot name is required")
+	}
+
+	name := flags.Arg(0)
+	flags.Parse(flags.Args()[1:])
+	if flags.NArg() > 0 {
+		return "", fmt.Errorf("unexpected arguments: %v", flags.Args())
+	}
+	if !snapshotNameRegex.MatchString(name) {
+		return "", fmt.Errorf("invalid snapshot name %q: use letters, digits, underscores and hyphens", name)
+	}
+	return name, nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"PROJECT_NAME/internal/spanwright"
+)
+
+// runValidate checks the databases against their expected-state files and prints a report
+func runValidate(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	database := flags.String("database", "", "Logical name or ID of the database (default: all configured databases)")
+	databaseID := flags.String("database-id", "", "Database ID to check instead of the configured one (requires --database)")
+	scenario := flags.String("scenario", "", "Scenario whose expected-<name>.yaml files to check")
+	file := flags.String("file", "", "Expected-state file to check instead of --scenario (requires --database)")
+	baseline := flags.String("baseline", "", "Snapshot saved by snapshot save that the changes section is checked against")
+	snapshotDir := flags.String("snapshot-dir", spanwright.DefaultSnapshotDir, "Directory the snapshots are kept in")
+	jsonOutput := flags.Bool("json", false, "Print the reports as JSON to stdout")
+	configFlags := spanwright.BindConfigFlags(flags)
+	flags.Parse(args)
+
+	if (*scenario == "") == (*file == "") {
+		return fmt.Errorf("exactly one of --scenario or --file is required")
+	}
+	if (*file != "" || *databaseID != "") && *database == "" {
+		return fmt.Errorf("--file and --database-id require --database")
+	}
+
+	config, err := spanwright.LoadConfigWithOptions(configFlags.Options())
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	databases := config.Databases
+	if *database != "" {
+		spec, err := config.Database(*database)
+		if err != nil {
+			return err
+		}
+		databases = []spanwright.DatabaseSpec{*spec}
+	}
+
+	ctx, cancel := config.WithTimeout(ctx)
+	defer cancel()
+
+	var reports []*spanwright.ValidationReport
+	failed := 0
+	for _, spec := range databases {
+		path := *file
+		if path == "" {
+			path = spec.ExpectedPath(config.ScenarioDir(*scenario))
+		}
+
+		baselinePath := ""
+		if *baseline != "" {
+			// The snapshot was saved under the ID of the database being checked
+			snapshotSpec := spec
+			if *databaseID != "" {
+				snapshotSpec.DatabaseID = *databaseID
+			}
+			baselinePath = snapshotSpec.SnapshotPath(*snapshotDir, *baseline)
+		}
+
+		report, err := validateDatabase(ctx, config, spec, *databaseID, path, baselinePath)
+		if err != nil {
+			return fmt.Errorf("%s: %w", spec.Name, err)
+		}
+		reports = append(reports, report)
+
+		if !report.Passed {
+			failed++
+		}
+		if !*jsonOutput {
+			printReport(report)
+		}
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(reports); err != nil {
+			return fmt.Errorf("failed to encode validation reports: %w", err)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d databases do not match their expected state", failed, len(reports))
+	}
+	return nil
+}
+
+func validateDatabase(ctx context.Context, config *spanwright.Config, spec spanwright.DatabaseSpec, databaseID, path, baselinePath string) (*spanwright.ValidationReport, error) {
+	expected, err := spanwright.LoadExpectedFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var baseline *spanwright.Snapshot
+	if baselinePath != "" {
+		if baseline, err = spanwright.LoadSnapshotFile(baselinePath); err != nil {
+			return nil, err
+		}
+	}
+
+	dbConfig, err := config.GetDatabaseConfig(spec.Name)
+	if err != nil {
+		return nil, err
+	}
+	if databaseID != "" {
+		dbConfig.DatabaseID = databaseID
+	}
+
+	dm, err := spanwright.NewDatabaseManager(ctx, dbConfig)
+	if err != nil {
+		return nil, err
+	}
+	defer dm.Close()
+
+	validator := spanwright.NewValidator(dm).WithDatabase(spec.Name, dm)
+	if baseline != nil {
+		validator.WithBaseline(baseline)
+	}
+	for _, name := range expected.Databases() {
+		if name == spec.Name {
+			continue
+		}
+
+		// Queries may read the other configured databases, e.g. to check references across them
+		otherConfig, err := config.GetDatabaseConfig(name)
+		if err != nil {
+			return nil, err
+		}
+		other, err := spanwright.NewDatabaseManager(ctx, otherConfig)
+		if err != nil {
+			return nil, err
+		}
+		defer other.Close()
+		validator.WithDatabase(name, other)
+	}
+
+	report, err := validator.Validate(ctx, expected)
+	if err != nil {
+		return nil, err
+	}
+	report.File = path
+	return report, nil
+}
+
+// printReport logs the result of each table of a report
+func printReport(report *spanwright.ValidationReport) {
+	for _, table := range report.Tables {
+		if table.Passed {
+			log.Printf("✅ %s %s: %d rows", report.DatabaseID, table.Table, table.ActualCount)
+			continue
+		}
+		for _, mismatch := range table.Mismatches {
+			log.Printf("❌ %s %s: %s", report.DatabaseID, table.Table, mismatch)
+		}
+	}
+	for _, table := range report.Changes {
+		if table.Passed {
+			log.Printf("✅ %s changes of %s: %d rows", report.DatabaseID, table.Table, table.ActualCount)
+			continue
+		}
+		for _, mismatch := range table.Mismatches {
+			log.Printf("❌ %s changes of %s: %s", report.DatabaseID, table.Table, mismatch)
+		}
+	}
+	for _, query := range report.Queries {
+		if query.Passed {
+			log.Printf("✅ %s query %s: %d rows", report.DatabaseID, query.Query, query.RowCount)
+			continue
+		}
+		for _, mismatch := range query.Mismatches {
+			log.Printf("❌ %s query %s: %s", report.DatabaseID, query.Query, mismatch)
+		}
+	}
+}
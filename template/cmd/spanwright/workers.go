@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"PROJECT_NAME/internal/spanwright"
+)
+
+// runWorkers creates a clone of every configured database for each parallel test worker,
+// applies the schema, seeds the scenario fixtures and writes the mapping tests read
+func runWorkers(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("workers", flag.ExitOnError)
+	count := flags.Int("count", 0, "Number of workers to create database clones for")
+	scenario := flags.String("scenario", "", "Scenario whose fixtures to seed into every clone (default: schema only)")
+	out := flags.String("out", spanwright.DefaultWorkersFile, "File to write the worker database mapping to")
+	cacheFile := flags.String("schema-cache", spanwright.DefaultSchemaCacheFile, "File recording the schema each database was built with")
+	rebuild := flags.Bool("rebuild", false, "Drop and recreate existing clones even when their schema is unchanged")
+	configFlags := spanwright.BindConfigFlags(flags)
+	flags.Parse(args)
+
+	if *count < 1 {
+		return fmt.Errorf("--count must be at least 1")
+	}
+
+	config, err := spanwright.LoadConfigWithOptions(configFlags.Options())
+	if err != nil {
+		return fmt.Errorf("configuration error: %w", err)
+	}
+
+	mapping, err := config.NewWorkerMapping(*count)
+	if err != nil {
+		return err
+	}
+
+	cache, err := loadSchemaCache(*cacheFile, *rebuild)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := config.WithTimeout(ctx)
+	defer cancel()
+
+	// Workers are set up concurrently; each one's databases are set up in order
+	errs := make([]error, *count)
+	var wg sync.WaitGroup
+	for worker := 0; worker < *count; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			errs[worker] = setupWorker(ctx, config, worker, *scenario, cache)
+		}(worker)
+	}
+	wg.Wait()
+	for worker, err := range errs {
+		if err != nil {
+			return fmt.Errorf("worker %d: %w", worker, err)
+		}
+	}
+
+	if err := mapping.WriteFile(*out); err != nil {
+		return err
+	}
+	log.Printf("✅ Set up %d workers; database mapping written to %s", *count, *out)
+	return nil
+}
+
+// setupWorker gives the clones of one worker the schema and seeds them
+func setupWorker(ctx context.Context, config *spanwright.Config, worker int, scenario string, cache *spanwright.SchemaCache) error {
+	workerConfig, err := config.ForWorker(worker)
+	if err != nil {
+		return err
+	}
+
+	for _, spec := range workerConfig.Databases {
+		if err := applySchema(ctx, workerConfig, spec, spec.SchemaPath, cache); err != nil {
+			return fmt.Errorf("%s: %w", spec.Name, err)
+		}
+		if scenario == "" {
+			continue
+		}
+
+		fixtureDir := spec.FixturePath(workerConfig.ScenarioDir(scenario))
+		if _, err := os.Stat(fixtureDir); os.IsNotExist(err) {
+			continue
+		}
+		if err := seedDatabase(ctx, workerConfig, spec, fixtureDir); err != nil {
+			return fmt.Errorf("%s: %w", spec.Name, err)
+		}
+	}
+	return nil
+}
+
+func seedDatabase(ctx context.Context, config *spanwright.Config, spec spanwright.DatabaseSpec, fixtureDir string) error {
+	files, err := spanwright.FixtureFiles(fixtureDir)
+	if err != nil {
+		return err
+	}
+
+	dbConfig, err := config.GetDatabaseConfig(spec.Name)
+	if err != nil {
+		return err
+	}
+
+	dm, err := spanwright.NewDatabaseManager(ctx, dbConfig)
+	if err != nil {
+		return err
+	}
+	defer dm.Close()
+
+	seededPath := spec.SeededPath(spanwright.DefaultSeededDir)
+	if err := dm.LoadSeededRows(seededPath); err != nil {
+		return err
+	}
+	if err := spanwright.NewSeeder(dm).SeedFiles(ctx, files); err != nil {
+		return err
+	}
+	return dm.SaveSeededRows(seededPath)
+}
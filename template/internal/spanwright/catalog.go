@@ -0,0 +1,671 @@
+package spanwright
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	"google.golang.org/grpc/codes"
+)
+
+// notNullCheckPrefix names the CHECK constraints Spanner reports for NOT NULL columns
+const notNullCheckPrefix = "CK_IS_NOT_NULL_"
+
+// DatabaseSchema describes the objects of a database, as read from information_schema
+type DatabaseSchema struct {
+	Dialect Dialect
+	// Schemas lists the named schemas; the default schema is the empty string and is not listed
+	Schemas       []string
+	Tables        []*Table
+	Views         []*View
+	Sequences     []*Sequence
+	ChangeStreams []*ChangeStream
+}
+
+// Table describes a table and everything defined on it
+type Table struct {
+	Schema     string
+	Name       string
+	Columns    []*Column
+	PrimaryKey []KeyColumn
+	// Parent is the qualified name of the table this one is interleaved in, if any
+	Parent string
+	// OnDelete is CASCADE or NO ACTION for interleaved tables
+	OnDelete         string
+	ForeignKeys      []*ForeignKey
+	Indexes          []*Index
+	CheckConstraints []*CheckConstraint
+}
+
+// Column describes a table column
+type Column struct {
+	Name     string
+	Position int64
+	// Type is the Spanner type, such as STRING(MAX) or ARRAY<INT64>
+	Type     string
+	Nullable bool
+	// Default is the default value expression, empty when there is none
+	Default              string
+	Generated            bool
+	GenerationExpression string
+	Stored               bool
+	AllowCommitTimestamp bool
+}
+
+// KeyColumn is a column of a primary key or index
+type KeyColumn struct {
+	Name       string
+	Descending bool
+}
+
+// ForeignKey describes a FOREIGN KEY constraint
+type ForeignKey struct {
+	Name    string
+	Columns []string
+	// ReferencedTable is the qualified name of the referenced table
+	ReferencedTable   string
+	ReferencedColumns []string
+	// OnDelete is CASCADE or NO ACTION
+	OnDelete string
+}
+
+// Index describes a secondary index
+type Index struct {
+	Name string
+	// Type is INDEX for secondary indexes; other values are index kinds such as SEARCH
+	Type         string
+	Unique       bool
+	NullFiltered bool
+	// Interleave is the qualified name of the table the index is interleaved in, if any
+	Interleave string
+	Columns    []KeyColumn
+	Storing    []string
+}
+
+// CheckConstraint describes a CHECK constraint
+type CheckConstraint struct {
+	Name       string
+	Expression string
+}
+
+// View describes a view
+type View struct {
+	Schema     string
+	Name       string
+	Definition string
+}
+
+// Sequence describes a sequence and its options, such as sequence_kind
+type Sequence struct {
+	Schema  string
+	Name    string
+	Options map[string]string
+}
+
+// ChangeStream describes a change stream and what it watches
+type ChangeStream struct {
+	Schema string
+	Name   string
+	// All reports whether the stream watches the whole database
+	All    bool
+	Tables []*ChangeStreamTable
+}
+
+// ChangeStreamTable is a table watched by a change stream
+type ChangeStreamTable struct {
+	// Table is the qualified table name
+	Table      string
+	AllColumns bool
+	Columns    []string
+}
+
+// qualifyName joins a schema and object name; objects of the default schema keep their plain name
+func qualifyName(schema, name string) string {
+	if schema == "" {
+		return name
+	}
+	return schema + "." + name
+}
+
+// QualifiedName returns the table name prefixed by its schema, if it is in a named schema
+func (t *Table) QualifiedName() string {
+	return qualifyName(t.Schema, t.Name)
+}
+
+// Column returns the named column, or nil
+func (t *Table) Column(name string) *Column {
+	for _, column := range t.Columns {
+		if column.Name == name {
+			return column
+		}
+	}
+	return nil
+}
+
+// ColumnTypes returns the Spanner type of each column, keyed by column name
+func (t *Table) ColumnTypes() map[string]string {
+	types := make(map[string]string, len(t.Columns))
+	for _, column := range t.Columns {
+		types[column.Name] = column.Type
+	}
+	return types
+}
+
+// QualifiedName returns the view name prefixed by its schema, if it is in a named schema
+func (v *View) QualifiedName() string {
+	return qualifyName(v.Schema, v.Name)
+}
+
+// Table returns the table with the given qualified name, or nil
+func (s *DatabaseSchema) Table(name string) *Table {
+	for _, table := range s.Tables {
+		if table.QualifiedName() == name {
+			return table
+		}
+	}
+	return nil
+}
+
+// TableNames returns the qualified names of all tables
+func (s *DatabaseSchema) TableNames() []string {
+	names := make([]string, 0, len(s.Tables))
+	for _, table := range s.Tables {
+		names = append(names, table.QualifiedName())
+	}
+	return names
+}
+
+// Dependencies returns the tables each table depends on, through interleaving or foreign
+// keys, keyed by qualified table name
+func (s *DatabaseSchema) Dependencies() map[string][]string {
+	dependencies := make(map[string][]string)
+	for _, table := range s.Tables {
+		name := table.QualifiedName()
+		if table.Parent != "" {
+			dependencies[name] = append(dependencies[name], table.Parent)
+		}
+		for _, fk := range table.ForeignKeys {
+			dependencies[name] = append(dependencies[name], fk.ReferencedTable)
+		}
+	}
+	return dependencies
+}
+
+// DescribeSchema reads the tables, views, sequences and change streams of every schema in
+// the database. Sections whose catalog tables the server does not provide, such as
+// sequences on older emulators, are left empty.
+func (dm *DatabaseManager) DescribeSchema(ctx context.Context) (*DatabaseSchema, error) {
+	ctx, cancel := dm.operationContext(ctx)
+	defer cancel()
+
+	dialect, err := dm.Dialect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &schemaReader{dm: dm, dialect: dialect, schema: &DatabaseSchema{Dialect: dialect}, tables: make(map[string]*Table)}
+	sections := []struct {
+		name     string
+		optional bool
+		read     func(context.Context) error
+	}{
+		{name: "schemas", read: r.readSchemas},
+		{name: "tables", read: r.readTables},
+		{name: "columns", read: r.readColumns},
+		{name: "column options", read: r.readColumnOptions},
+		{name: "indexes", read: r.readIndexes},
+		{name: "foreign keys", read: r.readForeignKeys},
+		{name: "check constraints", read: r.readCheckConstraints},
+		{name: "views", read: r.readViews},
+		{name: "sequences", optional: true, read: r.readSequences},
+		{name: "change streams", optional: true, read: r.readChangeStreams},
+	}
+
+	for _, section := range sections {
+		if err := section.read(ctx); err != nil {
+			if section.optional && isMissingCatalog(err) {
+				log.Printf("⚠️ Skipping %s: not supported by this server: %v", section.name, err)
+				continue
+			}
+			return nil, fmt.Errorf("failed to read %s: %w", section.name, err)
+		}
+	}
+
+	return r.schema, nil
+}
+
+// missingCatalogRegex matches the InvalidArgument messages of GoogleSQL and PostgreSQL
+// databases for a catalog table or column that does not exist
+var missingCatalogRegex = regexp.MustCompile(`(?i)table not found|unrecognized name|(relation|column) "[^"]*" does not exist`)
+
+// isMissingCatalog reports whether a catalog query failed because the catalog table or one
+// of its columns does not exist. Other invalid queries are not taken for a missing catalog.
+func isMissingCatalog(err error) bool {
+	switch spanner.ErrCode(err) {
+	case codes.NotFound, codes.Unimplemented:
+		return true
+	case codes.InvalidArgument:
+		return missingCatalogRegex.MatchString(err.Error())
+	}
+	return false
+}
+
+// schemaReader fills a DatabaseSchema one catalog section at a time
+type schemaReader struct {
+	dm      *DatabaseManager
+	dialect Dialect
+	schema  *DatabaseSchema
+	tables  map[string]*Table
+}
+
+// schemaName maps the dialect's default schema, such as public, to the empty string
+func (r *schemaReader) schemaName(schema string) string {
+	if schema == r.dialect.DefaultSchema() {
+		return ""
+	}
+	return schema
+}
+
+// qualify returns the qualified name of an object as reported by information_schema
+func (r *schemaReader) qualify(schema, name string) string {
+	return qualifyName(r.schemaName(schema), name)
+}
+
+// table returns the table a catalog row belongs to, or nil for objects that are not base
+// tables, such as views
+func (r *schemaReader) table(schema, name string) *Table {
+	return r.tables[r.qualify(schema, name)]
+}
+
+func (r *schemaReader) readSchemas(ctx context.Context) error {
+	stmt := r.dialect.Statement(`SELECT schema_name FROM information_schema.schemata
+		WHERE schema_name != `+r.dialect.Placeholder(1)+` AND schema_name NOT IN `+r.dialect.systemSchemas()+`
+		ORDER BY schema_name`, r.dialect.DefaultSchema())
+	schemas, err := QueryRows[string](ctx, r.dm, stmt)
+	if err != nil {
+		return err
+	}
+	r.schema.Schemas = schemas
+	return nil
+}
+
+func (r *schemaReader) readTables(ctx context.Context) error {
+	type row struct {
+		Schema   string             `spanner:"table_schema"`
+		Name     string             `spanner:"table_name"`
+		Parent   spanner.NullString `spanner:"parent_table_name"`
+		OnDelete spanner.NullString `spanner:"on_delete_action"`
+	}
+	stmt := spanner.NewStatement(`SELECT table_schema, table_name, parent_table_name, on_delete_action
+		FROM information_schema.tables
+		WHERE table_type = 'BASE TABLE' AND table_schema NOT IN ` + r.dialect.systemSchemas() + `
+		ORDER BY table_schema, table_name`)
+	rows, err := QueryRows[row](ctx, r.dm, stmt)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		table := &Table{Schema: r.schemaName(row.Schema), Name: row.Name, OnDelete: row.OnDelete.StringVal}
+		if row.Parent.Valid && row.Parent.StringVal != "" {
+			table.Parent = r.qualify(row.Schema, row.Parent.StringVal)
+		}
+		r.schema.Tables = append(r.schema.Tables, table)
+		r.tables[table.QualifiedName()] = table
+	}
+	return nil
+}
+
+func (r *schemaReader) readColumns(ctx context.Context) error {
+	type row struct {
+		Schema               string             `spanner:"table_schema"`
+		Table                string             `spanner:"table_name"`
+		Name                 string             `spanner:"column_name"`
+		Position             int64              `spanner:"ordinal_position"`
+		Type                 spanner.NullString `spanner:"spanner_type"`
+		IsNullable           string             `spanner:"is_nullable"`
+		Default              spanner.NullString `spanner:"column_default"`
+		IsGenerated          string             `spanner:"is_generated"`
+		GenerationExpression spanner.NullString `spanner:"generation_expression"`
+		IsStored             spanner.NullString `spanner:"is_stored"`
+	}
+	stmt := spanner.NewStatement(`SELECT table_schema, table_name, column_name, ordinal_position, spanner_type,
+			is_nullable, column_default, is_generated, generation_expression, is_stored
+		FROM information_schema.columns
+		WHERE table_schema NOT IN ` + r.dialect.systemSchemas() + `
+		ORDER BY table_schema, table_name, ordinal_position`)
+	rows, err := QueryRows[row](ctx, r.dm, stmt)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		table := r.table(row.Schema, row.Table)
+		if table == nil {
+			continue
+		}
+		table.Columns = append(table.Columns, &Column{
+			Name:                 row.Name,
+			Position:             row.Position,
+			Type:                 row.Type.StringVal,
+			Nullable:             row.IsNullable == "YES",
+			Default:              row.Default.StringVal,
+			Generated:            row.IsGenerated == "ALWAYS",
+			GenerationExpression: row.GenerationExpression.StringVal,
+			Stored:               row.IsStored.StringVal == "YES",
+			// PostgreSQL has a dedicated type instead of the allow_commit_timestamp option
+			AllowCommitTimestamp: r.dialect.IsPostgreSQL() && row.Type.StringVal == "spanner.commit_timestamp",
+		})
+	}
+	return nil
+}
+
+func (r *schemaReader) readColumnOptions(ctx context.Context) error {
+	if r.dialect.IsPostgreSQL() {
+		return nil
+	}
+
+	type row struct {
+		Schema string `spanner:"table_schema"`
+		Table  string `spanner:"table_name"`
+		Column string `spanner:"column_name"`
+		Value  string `spanner:"option_value"`
+	}
+	stmt := spanner.NewStatement(`SELECT table_schema, table_name, column_name, option_value
+		FROM information_schema.column_options
+		WHERE option_name = 'allow_commit_timestamp' AND table_schema NOT IN ` + r.dialect.systemSchemas())
+	rows, err := QueryRows[row](ctx, r.dm, stmt)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		table := r.table(row.Schema, row.Table)
+		if table == nil {
+			continue
+		}
+		if column := table.Column(row.Column); column != nil {
+			column.AllowCommitTimestamp = strings.EqualFold(row.Value, "TRUE")
+		}
+	}
+	return nil
+}
+
+func (r *schemaReader) readIndexes(ctx context.Context) error {
+	type indexRow struct {
+		Schema       string             `spanner:"table_schema"`
+		Table        string             `spanner:"table_name"`
+		Name         string             `spanner:"index_name"`
+		Type         string             `spanner:"index_type"`
+		Parent       spanner.NullString `spanner:"parent_table_name"`
+		Unique       bool               `spanner:"is_unique"`
+		NullFiltered bool               `spanner:"is_null_filtered"`
+	}
+	d := r.dialect
+	stmt := spanner.NewStatement(`SELECT table_schema, table_name, index_name, index_type, parent_table_name,
+			` + d.isTrue("is_unique") + ` AS is_unique, ` + d.isTrue("is_null_filtered") + ` AS is_null_filtered
+		FROM information_schema.indexes
+		WHERE index_type != 'PRIMARY_KEY' AND NOT ` + d.isTrue("spanner_is_managed") + `
+			AND table_schema NOT IN ` + d.systemSchemas() + `
+		ORDER BY table_schema, table_name, index_name`)
+	indexRows, err := QueryRows[indexRow](ctx, r.dm, stmt)
+	if err != nil {
+		return err
+	}
+
+	indexes := make(map[string]*Index)
+	for _, row := range indexRows {
+		table := r.table(row.Schema, row.Table)
+		if table == nil {
+			continue
+		}
+		index := &Index{Name: row.Name, Type: row.Type, Unique: row.Unique, NullFiltered: row.NullFiltered}
+		if row.Parent.Valid && row.Parent.StringVal != "" {
+			index.Interleave = r.qualify(row.Schema, row.Parent.StringVal)
+		}
+		table.Indexes = append(table.Indexes, index)
+		indexes[r.qualify(row.Schema, row.Name)] = index
+	}
+
+	type columnRow struct {
+		Schema   string             `spanner:"table_schema"`
+		Table    string             `spanner:"table_name"`
+		Index    string             `spanner:"index_name"`
+		Column   string             `spanner:"column_name"`
+		Position spanner.NullInt64  `spanner:"ordinal_position"`
+		Ordering spanner.NullString `spanner:"column_ordering"`
+	}
+	// Storing columns have no ordinal position and sort last
+	stmt = spanner.NewStatement(`SELECT table_schema, table_name, index_name, column_name, ordinal_position, column_ordering
+		FROM information_schema.index_columns
+		WHERE table_schema NOT IN ` + r.dialect.systemSchemas() + `
+		ORDER BY table_schema, table_name, index_name, ordinal_position IS NULL, ordinal_position, column_name`)
+	columnRows, err := QueryRows[columnRow](ctx, r.dm, stmt)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range columnRows {
+		key := KeyColumn{Name: row.Column, Descending: row.Ordering.StringVal == "DESC"}
+		if row.Index == "PRIMARY_KEY" {
+			if table := r.table(row.Schema, row.Table); table != nil && row.Position.Valid {
+				table.PrimaryKey = append(table.PrimaryKey, key)
+			}
+			continue
+		}
+
+		index, ok := indexes[r.qualify(row.Schema, row.Index)]
+		if !ok {
+			continue
+		}
+		if row.Position.Valid {
+			index.Columns = append(index.Columns, key)
+		} else {
+			index.Storing = append(index.Storing, row.Column)
+		}
+	}
+	return nil
+}
+
+func (r *schemaReader) readForeignKeys(ctx context.Context) error {
+	type row struct {
+		Schema           string `spanner:"table_schema"`
+		Table            string `spanner:"table_name"`
+		Name             string `spanner:"constraint_name"`
+		DeleteRule       string `spanner:"delete_rule"`
+		Column           string `spanner:"column_name"`
+		ReferencedSchema string `spanner:"referenced_schema"`
+		ReferencedTable  string `spanner:"referenced_table"`
+		ReferencedColumn string `spanner:"referenced_column"`
+	}
+	stmt := spanner.NewStatement(`SELECT tc.table_schema, tc.table_name, tc.constraint_name, rc.delete_rule,
+			kcu.column_name, ref.table_schema AS referenced_schema, ref.table_name AS referenced_table,
+			ref.column_name AS referenced_column
+		FROM information_schema.table_constraints AS tc
+		JOIN information_schema.referential_constraints AS rc
+			ON rc.constraint_schema = tc.constraint_schema AND rc.constraint_name = tc.constraint_name
+		JOIN information_schema.key_column_usage AS kcu
+			ON kcu.constraint_schema = tc.constraint_schema AND kcu.constraint_name = tc.constraint_name
+		JOIN information_schema.key_column_usage AS ref
+			ON ref.constraint_schema = rc.unique_constraint_schema AND ref.constraint_name = rc.unique_constraint_name
+			AND ref.ordinal_position = kcu.position_in_unique_constraint
+		WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema NOT IN ` + r.dialect.systemSchemas() + `
+		ORDER BY tc.table_schema, tc.table_name, tc.constraint_name, kcu.ordinal_position`)
+	rows, err := QueryRows[row](ctx, r.dm, stmt)
+	if err != nil {
+		return err
+	}
+
+	foreignKeys := make(map[string]*ForeignKey)
+	for _, row := range rows {
+		table := r.table(row.Schema, row.Table)
+		if table == nil {
+			continue
+		}
+		key := r.qualify(row.Schema, row.Name)
+		fk, ok := foreignKeys[key]
+		if !ok {
+			fk = &ForeignKey{
+				Name:            row.Name,
+				ReferencedTable: r.qualify(row.ReferencedSchema, row.ReferencedTable),
+				OnDelete:        row.DeleteRule,
+			}
+			foreignKeys[key] = fk
+			table.ForeignKeys = append(table.ForeignKeys, fk)
+		}
+		fk.Columns = append(fk.Columns, row.Column)
+		fk.ReferencedColumns = append(fk.ReferencedColumns, row.ReferencedColumn)
+	}
+	return nil
+}
+
+func (r *schemaReader) readCheckConstraints(ctx context.Context) error {
+	type row struct {
+		Schema     string `spanner:"table_schema"`
+		Table      string `spanner:"table_name"`
+		Name       string `spanner:"constraint_name"`
+		Expression string `spanner:"check_clause"`
+	}
+	stmt := spanner.NewStatement(`SELECT tc.table_schema, tc.table_name, tc.constraint_name, cc.check_clause
+		FROM information_schema.table_constraints AS tc
+		JOIN information_schema.check_constraints AS cc
+			ON cc.constraint_schema = tc.constraint_schema AND cc.constraint_name = tc.constraint_name
+		WHERE tc.constraint_type = 'CHECK' AND tc.table_schema NOT IN ` + r.dialect.systemSchemas() + `
+		ORDER BY tc.table_schema, tc.table_name, tc.constraint_name`)
+	rows, err := QueryRows[row](ctx, r.dm, stmt)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		// NOT NULL columns are already described by Column.Nullable
+		if strings.HasPrefix(strings.ToUpper(row.Name), notNullCheckPrefix) {
+			continue
+		}
+		if table := r.table(row.Schema, row.Table); table != nil {
+			table.CheckConstraints = append(table.CheckConstraints, &CheckConstraint{Name: row.Name, Expression: row.Expression})
+		}
+	}
+	return nil
+}
+
+func (r *schemaReader) readViews(ctx context.Context) error {
+	type row struct {
+		Schema     string             `spanner:"table_schema"`
+		Name       string             `spanner:"table_name"`
+		Definition spanner.NullString `spanner:"view_definition"`
+	}
+	stmt := spanner.NewStatement(`SELECT table_schema, table_name, view_definition FROM information_schema.views
+		WHERE table_schema NOT IN ` + r.dialect.systemSchemas() + `
+		ORDER BY table_schema, table_name`)
+	rows, err := QueryRows[row](ctx, r.dm, stmt)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		r.schema.Views = append(r.schema.Views, &View{Schema: r.schemaName(row.Schema), Name: row.Name, Definition: row.Definition.StringVal})
+	}
+	return nil
+}
+
+func (r *schemaReader) readSequences(ctx context.Context) error {
+	type row struct {
+		Schema string             `spanner:"sequence_schema"`
+		Name   string             `spanner:"sequence_name"`
+		Option spanner.NullString `spanner:"option_name"`
+		Value  spanner.NullString `spanner:"option_value"`
+	}
+	stmt := spanner.NewStatement(`SELECT s.schema AS sequence_schema, s.name AS sequence_name, o.option_name, o.option_value
+		FROM information_schema.sequences AS s
+		LEFT JOIN information_schema.sequence_options AS o ON o.schema = s.schema AND o.name = s.name
+		ORDER BY s.schema, s.name, o.option_name`)
+	if r.dialect.IsPostgreSQL() {
+		// PostgreSQL has the standard sequences view and no sequence_options
+		stmt = spanner.NewStatement(`SELECT sequence_schema, sequence_name FROM information_schema.sequences
+			WHERE sequence_schema NOT IN ` + r.dialect.systemSchemas() + `
+			ORDER BY sequence_schema, sequence_name`)
+	}
+	rows, err := QueryRows[row](ctx, r.dm, stmt)
+	if err != nil {
+		return err
+	}
+
+	sequences := make(map[string]*Sequence)
+	for _, row := range rows {
+		name := r.qualify(row.Schema, row.Name)
+		sequence, ok := sequences[name]
+		if !ok {
+			sequence = &Sequence{Schema: r.schemaName(row.Schema), Name: row.Name, Options: make(map[string]string)}
+			sequences[name] = sequence
+			r.schema.Sequences = append(r.schema.Sequences, sequence)
+		}
+		if row.Option.Valid {
+			sequence.Options[row.Option.StringVal] = row.Value.StringVal
+		}
+	}
+	return nil
+}
+
+func (r *schemaReader) readChangeStreams(ctx context.Context) error {
+	type streamRow struct {
+		Schema string `spanner:"change_stream_schema"`
+		Name   string `spanner:"change_stream_name"`
+		All    bool   `spanner:"all"`
+	}
+	// ALL is a reserved keyword and has to be quoted
+	stmt := spanner.NewStatement(`SELECT change_stream_schema, change_stream_name,
+			` + r.dialect.isTrue(r.dialect.QuoteIdentifier("all")) + ` AS ` + r.dialect.QuoteIdentifier("all") + `
+		FROM information_schema.change_streams
+		ORDER BY change_stream_schema, change_stream_name`)
+	streamRows, err := QueryRows[streamRow](ctx, r.dm, stmt)
+	if err != nil {
+		return err
+	}
+
+	streams := make(map[string]*ChangeStream)
+	for _, row := range streamRows {
+		stream := &ChangeStream{Schema: r.schemaName(row.Schema), Name: row.Name, All: row.All}
+		streams[r.qualify(row.Schema, row.Name)] = stream
+		r.schema.ChangeStreams = append(r.schema.ChangeStreams, stream)
+	}
+
+	type tableRow struct {
+		StreamSchema string             `spanner:"change_stream_schema"`
+		Stream       string             `spanner:"change_stream_name"`
+		Schema       string             `spanner:"table_schema"`
+		Table        string             `spanner:"table_name"`
+		AllColumns   bool               `spanner:"all_columns"`
+		Column       spanner.NullString `spanner:"column_name"`
+	}
+	stmt = spanner.NewStatement(`SELECT t.change_stream_schema, t.change_stream_name, t.table_schema, t.table_name,
+			` + r.dialect.isTrue("t.all_columns") + ` AS all_columns, c.column_name
+		FROM information_schema.change_stream_tables AS t
+		LEFT JOIN information_schema.change_stream_columns AS c
+			ON c.change_stream_schema = t.change_stream_schema AND c.change_stream_name = t.change_stream_name
+			AND c.table_schema = t.table_schema AND c.table_name = t.table_name
+		ORDER BY t.change_stream_schema, t.change_stream_name, t.table_schema, t.table_name, c.column_name`)
+	tableRows, err := QueryRows[tableRow](ctx, r.dm, stmt)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range tableRows {
+		stream, ok := streams[r.qualify(row.StreamSchema, row.Stream)]
+		if !ok {
+			continue
+		}
+		name := r.qualify(row.Schema, row.Table)
+		if n := len(stream.Tables); n == 0 || stream.Tables[n-1].Table != name {
+			stream.Tables = append(stream.Tables, &ChangeStreamTable{Table: name, AllColumns: row.AllColumns})
+		}
+		if row.Column.Valid {
+			table := stream.Tables[len(stream.Tables)-1]
+			table.Columns = append(table.Columns, row.Column.StringVal)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package spanwright
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func testSchema() *DatabaseSchema {
+	return &DatabaseSchema{
+		Schemas: []string{"sales"},
+		Tables: []*Table{
+			{Name: "Users", Columns: []*Column{
+				{Name: "UserID", Type: "STRING(36)"},
+				{Name: "Name", Type: "STRING(MAX)", Nullable: true},
+				{Name: "NameLength", Type: "INT64", Generated: true},
+			}},
+			{Name: "Posts", Parent: "Users", OnDelete: "CASCADE"},
+			{Schema: "sales", Name: "Orders", ForeignKeys: []*ForeignKey{
+				{Name: "FK_Orders_Users", Columns: []string{"UserID"}, ReferencedTable: "Users", ReferencedColumns: []string{"UserID"}},
+				{Name: "FK_Orders_Products", Columns: []string{"ProductID"}, ReferencedTable: "sales.Products", ReferencedColumns: []string{"ProductID"}},
+			}},
+			{Schema: "sales", Name: "Products"},
+		},
+	}
+}
+
+func TestDatabaseSchemaTable(t *testing.T) {
+	schema := testSchema()
+
+	if table := schema.Table("sales.Orders"); table == nil || table.Name != "Orders" {
+		t.Errorf("Table(sales.Orders) = %v, want the Orders table", table)
+	}
+	if table := schema.Table("Orders"); table != nil {
+		t.Errorf("Table(Orders) = %v, want nil for a table in a named schema", table)
+	}
+
+	want := []string{"Users", "Posts", "sales.Orders", "sales.Products"}
+	if got := schema.TableNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("TableNames() = %v, want %v", got, want)
+	}
+
+	users := schema.Table("Users")
+	if got, want := users.ColumnTypes(), map[string]string{"UserID": "STRING(36)", "Name": "STRING(MAX)", "NameLength": "INT64"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ColumnTypes() = %v, want %v", got, want)
+	}
+	if users.Column("Missing") != nil {
+		t.Error("Column(Missing) expected nil")
+	}
+}
+
+func TestDatabaseSchemaDependencies(t *testing.T) {
+	want := map[string][]string{
+		"Posts":        {"Users"},
+		"sales.Orders": {"Users", "sales.Products"},
+	}
+	if got := testSchema().Dependencies(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Dependencies() = %v, want %v", got, want)
+	}
+}
+
+func TestBuildInsertMutationsRejectsGeneratedColumns(t *testing.T) {
+	fixture := &Fixture{Table: "Users", File: "Users.yaml", Rows: []map[string]interface{}{
+		{"UserID": "u1", "NameLength": 3},
+	}}
+
+	_, err := buildInsertMutations(fixture, testSchema().Table("Users"), DialectGoogleSQL)
+	if err == nil || !strings.Contains(err.Error(), "generated") {
+		t.Errorf("buildInsertMutations() error = %v, want a generated column error", err)
+	}
+}
+
+func TestIsMissingCatalog(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "not found", err: status.Error(codes.NotFound, "not found"), want: true},
+		{name: "unimplemented", err: status.Error(codes.Unimplemented, "unsupported"), want: true},
+		{name: "googlesql table", err: status.Error(codes.InvalidArgument, "Table not found: INFORMATION_SCHEMA.CHANGE_STREAMS"), want: true},
+		{name: "googlesql column", err: status.Error(codes.InvalidArgument, "Unrecognized name: SEQUENCE_KIND"), want: true},
+		{name: "postgresql table", err: status.Error(codes.InvalidArgument, `relation "information_schema.sequences" does not exist`), want: true},
+		{name: "postgresql column", err: status.Error(codes.InvalidArgument, `column "sequence_kind" does not exist`), want: true},
+		{name: "syntax error", err: status.Error(codes.InvalidArgument, "Syntax error: Unexpected end of statement"), want: false},
+		{name: "parameter type", err: status.Error(codes.InvalidArgument, "No matching signature for operator = for argument types: STRING, INT64"), want: false},
+		{name: "unavailable", err: status.Error(codes.Unavailable, "table not found"), want: false},
+		{name: "plain error", err: errors.New("table not found"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isMissingCatalog(tt.err); got != tt.want {
+				t.Errorf("isMissingCatalog(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildInsertMutationsRejectsDuplicateKeys(t *testing.T) {
+	posts := seededTestSchema().Table("Posts")
+
+	// 2 and "2" are the same INT64 key
+	fixture := &Fixture{Table: "Posts", File: "Posts.yaml", Rows: []map[string]interface{}{
+		{"UserID": "user-001", "PostID": 1},
+		{"UserID": "user-001", "PostID": 2},
+		{"UserID": "user-002", "PostID": 2},
+		{"UserID": "user-001", "PostID": "2"},
+	}}
+	_, err := buildInsertMutations(fixture, posts, DialectGoogleSQL)
+	want := `fixture Posts.yaml row 4: table Posts already has a row with primary key ("user-001", "2") in row 2`
+	if err == nil || err.Error() != want {
+		t.Errorf("buildInsertMutations() error = %v, want %q", err, want)
+	}
+
+	// Rows leaving part of the key to a default cannot be compared
+	fixture = &Fixture{Table: "Posts", File: "Posts.yaml", Rows: []map[string]interface{}{
+		{"UserID": "user-001"},
+		{"UserID": "user-001"},
+	}}
+	if _, err := buildInsertMutations(fixture, posts, DialectGoogleSQL); err != nil {
+		t.Errorf("buildInsertMutations() without full keys error = %v", err)
+	}
+}
@@ -0,0 +1,320 @@
+package spanwright
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ChangeExpectation describes what should have changed since a baseline snapshot. With
+// Exact, tables it does not list must not have changed at all.
+type ChangeExpectation struct {
+	Exact  bool
+	Tables []*TableChangeExpectation
+}
+
+// TableChangeExpectation describes the changes of one table. Inserted rows must match
+// different inserted rows in any order, Updated rows are addressed by primary key and give
+// values after the update, and Deleted lists primary keys. With Exact, any other change to
+// the table fails, including columns an update changed but Updated does not list.
+type TableChangeExpectation struct {
+	Table    string
+	Line     int
+	Exact    bool
+	Inserted []ExpectedRow
+	Updated  []KeyedRow
+	Deleted  [][]interface{}
+}
+
+// parseChangeExpectation parses the changes section of an expected-state file
+func parseChangeExpectation(node *yaml.Node) (*ChangeExpectation, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: changes must be a mapping with tables", node.Line)
+	}
+
+	changes := &ChangeExpectation{}
+	for i := 0; i < len(node.Content); i += 2 {
+		field, value := node.Content[i], node.Content[i+1]
+		switch field.Value {
+		case "exact":
+			if err := value.Decode(&changes.Exact); err != nil {
+				return nil, fmt.Errorf("line %d: changes exact must be true or false", value.Line)
+			}
+		case "tables":
+			if value.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("line %d: changes tables must be a mapping of table names", value.Line)
+			}
+			for j := 0; j < len(value.Content); j += 2 {
+				table, err := parseTableChangeExpectation(value.Content[j], value.Content[j+1])
+				if err != nil {
+					return nil, fmt.Errorf("changes of table %s: %w", value.Content[j].Value, err)
+				}
+				changes.Tables = append(changes.Tables, table)
+			}
+		default:
+			return nil, fmt.Errorf("line %d: unknown key %q in changes", field.Line, field.Value)
+		}
+	}
+	return changes, nil
+}
+
+// parseTableChangeExpectation parses the expected changes of one table
+func parseTableChangeExpectation(key, node *yaml.Node) (*TableChangeExpectation, error) {
+	table := &TableChangeExpectation{Table: key.Value, Line: key.Line}
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: expected a mapping", node.Line)
+	}
+
+	for i := 0; i < len(node.Content); i += 2 {
+		field, value := node.Content[i], node.Content[i+1]
+		var err error
+		switch field.Value {
+		case "exact":
+			if value.Decode(&table.Exact) != nil {
+				err = fmt.Errorf("line %d: exact must be true or false", value.Line)
+			}
+		case "inserted":
+			table.Inserted, err = parseExpectedRows(value, false)
+		case "updated":
+			table.Updated, err = parseKeyedRows(value)
+		case "deleted":
+			table.Deleted, err = parseKeys(value)
+		default:
+			err = fmt.Errorf("line %d: unknown key %q", field.Line, field.Value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return table, nil
+}
+
+// checkChanges compares the difference from the baseline with the expected changes
+func checkChanges(expected *ChangeExpectation, diff *SnapshotDiff, now time.Time) []*TableResult {
+	var results []*TableResult
+	listed := map[string]bool{}
+	for _, table := range expected.Tables {
+		listed[table.Table] = true
+		results = append(results, checkTableChanges(table, diff.Table(table.Table), now))
+	}
+
+	if expected.Exact {
+		for _, td := range diff.Tables {
+			if listed[td.Table] {
+				continue
+			}
+			results = append(results, &TableResult{
+				Table:       td.Table,
+				ActualCount: td.changeCount(),
+				Mismatches: []Mismatch{{
+					Message: fmt.Sprintf("unexpected changes: %d inserted, %d updated, %d deleted", len(td.Inserted), len(td.Updated), len(td.Deleted)),
+				}},
+			})
+		}
+	}
+	return results
+}
+
+// checkTableChanges compares the changes of one table; td is nil when it did not change
+func checkTableChanges(expected *TableChangeExpectation, td *TableDiff, now time.Time) *TableResult {
+	result := &TableResult{Table: expected.Table}
+	if td == nil {
+		for i := range expected.Inserted {
+			result.Mismatches = append(result.Mismatches, Mismatch{ExpectedRow: i + 1, Message: "no rows were inserted"})
+		}
+		for _, keyed := range expected.Updated {
+			result.Mismatches = append(result.Mismatches, Mismatch{Key: formatKey(keyed.Key), Message: "row was not updated"})
+		}
+		for _, key := range expected.Deleted {
+			result.Mismatches = append(result.Mismatches, Mismatch{Key: formatKey(key), Message: "row was not deleted"})
+		}
+		result.Passed = len(result.Mismatches) == 0
+		return result
+	}
+	result.ActualCount = td.changeCount()
+
+	// Inserted rows match like a set of table rows
+	inserted := td.matcher(td.Inserted, now)
+	claimed := make([]bool, len(td.Inserted))
+	for _, mismatch := range inserted.matchSet(expected.Inserted, claimed) {
+		if mismatch.Row > 0 && mismatch.Key == "" {
+			mismatch.Key = td.Inserted[mismatch.Row-1].Key
+		}
+		result.Mismatches = append(result.Mismatches, mismatch)
+	}
+	if expected.Exact {
+		for i, change := range td.Inserted {
+			if !claimed[i] {
+				result.Mismatches = append(result.Mismatches, Mismatch{Key: change.Key, Message: "unexpected inserted row"})
+			}
+		}
+	}
+
+	// Updated rows are looked up by key and compared with their values after the update
+	updated := td.matcher(td.Updated, now)
+	updates, err := td.keyIndex(td.Updated)
+	if err != nil {
+		result.Mismatches = append(result.Mismatches, Mismatch{Message: err.Error()})
+	}
+	seen := map[int]bool{}
+	for _, keyed := range expected.Updated {
+		key := formatKey(keyed.Key)
+		i, err := td.find(updates, keyed.Key)
+		switch {
+		case err != nil:
+			result.Mismatches = append(result.Mismatches, Mismatch{Key: key, Message: err.Error()})
+			continue
+		case i < 0:
+			result.Mismatches = append(result.Mismatches, Mismatch{Key: key, Message: "row was not updated"})
+			continue
+		}
+
+		seen[i] = true
+		for _, mismatch := range updated.match(i, keyed.Row) {
+			mismatch.Key = key
+			result.Mismatches = append(result.Mismatches, mismatch)
+		}
+		if expected.Exact {
+			result.Mismatches = append(result.Mismatches, td.unlistedChanges(td.Updated[i], keyed.Row)...)
+		}
+	}
+	if expected.Exact {
+		for i, change := range td.Updated {
+			if !seen[i] {
+				result.Mismatches = append(result.Mismatches, Mismatch{Key: change.Key, Message: "unexpected update of " + strings.Join(change.Changed, ", ")})
+			}
+		}
+	}
+
+	// Deleted rows are only compared by key
+	deletions, err := td.keyIndex(td.Deleted)
+	if err != nil {
+		result.Mismatches = append(result.Mismatches, Mismatch{Message: err.Error()})
+	}
+	seen = map[int]bool{}
+	for _, key := range expected.Deleted {
+		i, err := td.find(deletions, key)
+		switch {
+		case err != nil:
+			result.Mismatches = append(result.Mismatches, Mismatch{Key: formatKey(key), Message: err.Error()})
+		case i < 0:
+			result.Mismatches = append(result.Mismatches, Mismatch{Key: formatKey(key), Message: "row was not deleted"})
+		default:
+			seen[i] = true
+		}
+	}
+	if expected.Exact {
+		for i, change := range td.Deleted {
+			if !seen[i] {
+				result.Mismatches = append(result.Mismatches, Mismatch{Key: change.Key, Message: "unexpected deleted row"})
+			}
+		}
+	}
+
+	result.Passed = len(result.Mismatches) == 0
+	return result
+}
+
+// changeCount returns the number of changed rows
+func (td *TableDiff) changeCount() int {
+	return len(td.Inserted) + len(td.Updated) + len(td.Deleted)
+}
+
+// matcher returns a rowMatcher over the values of changed rows after the change
+func (td *TableDiff) matcher(changes []RowChange, now time.Time) *rowMatcher {
+	dump := &TableDump{Table: td.Table, Columns: td.Columns}
+	for _, change := range changes {
+		dump.Rows = append(dump.Rows, change.After)
+	}
+
+	types := make(map[string]columnType, len(td.Columns))
+	for i, column := range td.Columns {
+		types[column] = td.types[i]
+	}
+	return newRowMatcher(dump, types, td.Key, now)
+}
+
+// canonicalKey returns text that is equal for equal primary keys of the table
+func (td *TableDiff) canonicalKey(values []interface{}) (string, error) {
+	if len(values) != len(td.Key) {
+		return "", fmt.Errorf("key has %d values, but the primary key has %d columns", len(values), len(td.Key))
+	}
+
+	types := make([]columnType, len(td.Key))
+	for i, key := range td.Key {
+		for j, column := range td.Columns {
+			if column == key {
+				types[i] = td.types[j]
+			}
+		}
+	}
+	return canonicalRow(values, types)
+}
+
+// keyIndex maps the canonical primary key of each change to its position
+func (td *TableDiff) keyIndex(changes []RowChange) (map[string]int, error) {
+	index := make(map[string]int, len(changes))
+	for i, change := range changes {
+		row := change.After
+		if row == nil {
+			row = change.Before
+		}
+
+		values := make([]interface{}, len(td.Key))
+		for j, key := range td.Key {
+			for k, column := range td.Columns {
+				if column == key {
+					values[j] = row[k]
+				}
+			}
+		}
+
+		key, err := td.canonicalKey(values)
+		if err != nil {
+			return nil, err
+		}
+		index[key] = i
+	}
+	return index, nil
+}
+
+// find returns the position of the change with the given primary key, or -1
+func (td *TableDiff) find(index map[string]int, key []interface{}) (int, error) {
+	canonical, err := td.canonicalKey(key)
+	if err != nil {
+		return -1, err
+	}
+	if i, ok := index[canonical]; ok {
+		return i, nil
+	}
+	return -1, nil
+}
+
+// unlistedChanges reports the columns an update changed that the expected row leaves out
+func (td *TableDiff) unlistedChanges(change RowChange, expected ExpectedRow) []Mismatch {
+	listed := map[string]bool{}
+	for _, value := range expected {
+		listed[value.Column] = true
+	}
+
+	var mismatches []Mismatch
+	for _, column := range change.Changed {
+		if listed[column] {
+			continue
+		}
+		for i, name := range td.Columns {
+			if name == column {
+				mismatches = append(mismatches, Mismatch{
+					Key:      change.Key,
+					Column:   column,
+					Expected: change.Before[i],
+					Actual:   change.After[i],
+					Message:  fmt.Sprintf("also changed from %s to %s", formatValue(change.Before[i]), formatValue(change.After[i])),
+				})
+			}
+		}
+	}
+	return mismatches
+}
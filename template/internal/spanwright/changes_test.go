@@ -0,0 +1,125 @@
+package spanwright
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckChanges(t *testing.T) {
+	before, after := testSnapshots()
+	diff, err := Diff(before, after)
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name: "exact changes",
+			content: `changes:
+  exact: true
+  tables:
+    UserLogs:
+      inserted:
+        - {UserID: user-001, Action: login}
+    Users:
+      updated:
+        user-001: {Status: 2, UpdatedAt: !after 2024-01-01T00:00:00Z}
+      deleted: [user-003]
+`,
+		},
+		{
+			name: "partial changes",
+			content: `changes:
+  tables:
+    Users:
+      updated:
+        user-001: {Status: 2}
+`,
+		},
+		{
+			name: "unlisted changes",
+			content: `changes:
+  exact: true
+  tables:
+    Users:
+      exact: true
+      updated:
+        user-001: {Status: 2}
+`,
+			want: []string{
+				`Users: key "user-001" column UpdatedAt also changed from "2024-01-01T00:00:00Z" to "2024-06-01T12:00:00Z"`,
+				`Users: key "user-003" unexpected deleted row`,
+				"UserLogs: unexpected changes: 1 inserted, 0 updated, 0 deleted",
+			},
+		},
+		{
+			name: "missing changes",
+			content: `changes:
+  tables:
+    UserLogs:
+      inserted:
+        - {Action: logout}
+    Users:
+      updated:
+        user-002: {Status: 2}
+      deleted: [user-001]
+    Products:
+      deleted: [product-001]
+`,
+			want: []string{
+				`UserLogs: expected row 1: key "log-001" column Action expected "logout", got "login"`,
+				`Users: key "user-002" row was not updated`,
+				`Users: key "user-001" row was not deleted`,
+				`Products: key "product-001" row was not deleted`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, err := ParseExpected([]byte(tt.content))
+			if err != nil {
+				t.Fatalf("ParseExpected() error = %v", err)
+			}
+
+			var got []string
+			for _, result := range checkChanges(state.Changes, diff, time.Now()) {
+				for _, mismatch := range result.Mismatches {
+					got = append(got, result.Table+": "+mismatch.String())
+				}
+				if result.Passed != (len(result.Mismatches) == 0) {
+					t.Errorf("%s Passed = %v with %d mismatches", result.Table, result.Passed, len(result.Mismatches))
+				}
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("checkChanges() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestParseChangeExpectationErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		wantErr string
+	}{
+		{content: "changes: []\n", wantErr: "mapping with tables"},
+		{content: "changes:\n  tables:\n    Users:\n      changed: []\n", wantErr: `unknown key "changed"`},
+		{content: "changes:\n  tables:\n    Users:\n      deleted: {a: 1}\n", wantErr: "list of primary keys"},
+		{content: "changes:\n  only: true\n", wantErr: `unknown key "only" in changes`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.wantErr, func(t *testing.T) {
+			_, err := ParseExpected([]byte(tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseExpected() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,392 @@
+package spanwright
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigFile is the project config file read when none is given
+const DefaultConfigFile = "spanwright.yaml"
+
+// ConfigSource identifies the layer a configuration value was resolved from
+type ConfigSource string
+
+// Configuration layers, from lowest to highest precedence
+const (
+	SourceDefault ConfigSource = "default"
+	SourceFile    ConfigSource = "file"
+	SourceDotEnv  ConfigSource = ".env"
+	SourceEnv     ConfigSource = "env"
+	SourceFlag    ConfigSource = "flag"
+)
+
+// ConfigSetting is a resolved configuration value and the layer that set it
+type ConfigSetting struct {
+	Key    string
+	Value  string
+	Source ConfigSource
+}
+
+// configDefaults are the lowest-precedence values, keyed like the environment variables
+var configDefaults = map[string]string{
+	"ENVIRONMENT":         "development",
+	"TIMEOUT_SECONDS":     "120",
+	"SCENARIOS_DIR":       "scenarios",
+	"RETRY_MAX_ATTEMPTS":  strconv.Itoa(DefaultRetryPolicy.MaxAttempts),
+	"RETRY_INITIAL_DELAY": DefaultRetryPolicy.InitialDelay.String(),
+	"RETRY_MAX_DELAY":     DefaultRetryPolicy.MaxDelay.String(),
+	"RETRY_MULTIPLIER":    strconv.FormatFloat(DefaultRetryPolicy.Multiplier, 'g', -1, 64),
+	"RETRY_JITTER":        strconv.FormatFloat(DefaultRetryPolicy.Jitter, 'g', -1, 64),
+	"RETRY_MAX_ELAPSED":   DefaultRetryPolicy.MaxElapsed.String(),
+	"RETRY_CODES":         "ABORTED,UNAVAILABLE,RESOURCE_EXHAUSTED,INTERNAL,DEADLINE_EXCEEDED",
+}
+
+// LoadOptions controls where configuration is read from
+type LoadOptions struct {
+	// ConfigFile is the project config file; empty means $SPANWRIGHT_CONFIG, or
+	// spanwright.yaml when it exists
+	ConfigFile string
+	// EnvFile is the dotenv file; empty means .env when it exists
+	EnvFile string
+	// Flags holds values set on the command line, keyed like the environment variables
+	Flags map[string]string
+}
+
+// fileConfig is the layout of spanwright.yaml
+type fileConfig struct {
+	ProjectID            string   `yaml:"project_id"`
+	InstanceID           string   `yaml:"instance_id"`
+	EmulatorHost         string   `yaml:"emulator_host"`
+	AllowedEmulatorHosts []string `yaml:"allowed_emulator_hosts"`
+	AllowNonEmulator     string   `yaml:"allow_non_emulator"`
+	Environment          string   `yaml:"environment"`
+	TimeoutSeconds       int      `yaml:"timeout_seconds"`
+	ScenariosDir         string   `yaml:"scenarios_dir"`
+	Retry                struct {
+		MaxAttempts  int      `yaml:"max_attempts"`
+		InitialDelay string   `yaml:"initial_delay"`
+		MaxDelay     string   `yaml:"max_delay"`
+		Multiplier   string   `yaml:"multiplier"`
+		Jitter       string   `yaml:"jitter"`
+		MaxElapsed   string   `yaml:"max_elapsed"`
+		Codes        []string `yaml:"codes"`
+	} `yaml:"retry"`
+	Databases []struct {
+		Name         string `yaml:"name"`
+		DatabaseID   string `yaml:"database_id"`
+		SchemaPath   string `yaml:"schema_path"`
+		FixtureDir   string `yaml:"fixture_dir"`
+		ExpectedFile string `yaml:"expected_file"`
+		Dialect      string `yaml:"dialect"`
+	} `yaml:"databases"`
+}
+
+// parseConfigFile reads spanwright.yaml into values keyed like the environment variables
+func parseConfigFile(data []byte) (map[string]string, error) {
+	var file fileConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	values := map[string]string{
+		"PROJECT_ID":                     file.ProjectID,
+		"INSTANCE_ID":                    file.InstanceID,
+		"SPANNER_EMULATOR_HOST":          file.EmulatorHost,
+		"SPANNER_EMULATOR_ALLOWED_HOSTS": strings.Join(file.AllowedEmulatorHosts, ","),
+		"ALLOW_NON_EMULATOR":             file.AllowNonEmulator,
+		"ENVIRONMENT":                    file.Environment,
+		"SCENARIOS_DIR":                  file.ScenariosDir,
+		"RETRY_INITIAL_DELAY":            file.Retry.InitialDelay,
+		"RETRY_MAX_DELAY":                file.Retry.MaxDelay,
+		"RETRY_MULTIPLIER":               file.Retry.Multiplier,
+		"RETRY_JITTER":                   file.Retry.Jitter,
+		"RETRY_MAX_ELAPSED":              file.Retry.MaxElapsed,
+		"RETRY_CODES":                    strings.Join(file.Retry.Codes, ","),
+	}
+	if file.TimeoutSeconds != 0 {
+		values["TIMEOUT_SECONDS"] = strconv.Itoa(file.TimeoutSeconds)
+	}
+	if file.Retry.MaxAttempts != 0 {
+		values["RETRY_MAX_ATTEMPTS"] = strconv.Itoa(file.Retry.MaxAttempts)
+	}
+
+	var names []string
+	for i, db := range file.Databases {
+		if db.Name == "" {
+			return nil, fmt.Errorf("databases[%d]: name is required", i)
+		}
+		names = append(names, db.Name)
+		values[databaseEnvKey(db.Name, "DATABASE_ID")] = db.DatabaseID
+		values[databaseEnvKey(db.Name, "SCHEMA_PATH")] = db.SchemaPath
+		values[databaseEnvKey(db.Name, "FIXTURE_DIR")] = db.FixtureDir
+		values[databaseEnvKey(db.Name, "EXPECTED_FILE")] = db.ExpectedFile
+		values[databaseEnvKey(db.Name, "DIALECT")] = db.Dialect
+	}
+	values["DATABASES"] = strings.Join(names, ",")
+
+	return values, nil
+}
+
+// configResolver looks values up through the configuration layers and records where
+// each one came from
+type configResolver struct {
+	file      map[string]string
+	dotenv    map[string]string
+	flags     map[string]string
+	lookupEnv func(string) (string, bool)
+
+	settings map[string]ConfigSetting
+	err      error
+}
+
+// newEnvResolver returns a resolver over defaults and the process environment only
+func newEnvResolver() *configResolver {
+	return &configResolver{lookupEnv: os.LookupEnv, settings: make(map[string]ConfigSetting)}
+}
+
+// lookup returns the highest-precedence non-empty value of key
+func (r *configResolver) lookup(key string) (string, ConfigSource) {
+	if value := r.flags[key]; value != "" {
+		return value, SourceFlag
+	}
+	if value, ok := r.lookupEnv(key); ok && value != "" {
+		return value, SourceEnv
+	}
+	if value := r.dotenv[key]; value != "" {
+		return value, SourceDotEnv
+	}
+	if value := r.file[key]; value != "" {
+		return value, SourceFile
+	}
+	if value := configDefaults[key]; value != "" {
+		return value, SourceDefault
+	}
+	return "", ""
+}
+
+// getWithDefault resolves key, falling back to defaultValue
+func (r *configResolver) getWithDefault(key, defaultValue string) string {
+	value, source := r.lookup(key)
+	if source == "" && defaultValue != "" {
+		value, source = defaultValue, SourceDefault
+	}
+	if source != "" {
+		r.settings[key] = ConfigSetting{Key: key, Value: value, Source: source}
+	}
+	return value
+}
+
+// get resolves key
+func (r *configResolver) get(key string) string {
+	return r.getWithDefault(key, "")
+}
+
+// getList resolves a comma-separated key
+func (r *configResolver) getList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(r.get(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getInt resolves an integer key; a malformed value is recorded in r.err
+func (r *configResolver) getInt(key string) int {
+	value := r.get(key)
+	if value == "" {
+		return 0
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		r.fail(key, fmt.Errorf("invalid integer %q", value))
+	}
+	return parsed
+}
+
+// getFloat resolves a floating-point key; a malformed value is recorded in r.err
+func (r *configResolver) getFloat(key string) float64 {
+	value := r.get(key)
+	if value == "" {
+		return 0
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		r.fail(key, fmt.Errorf("invalid number %q", value))
+	}
+	return parsed
+}
+
+// getDuration resolves a duration key; a malformed value is recorded in r.err
+func (r *configResolver) getDuration(key string) time.Duration {
+	value := r.get(key)
+	if value == "" {
+		return 0
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		r.fail(key, fmt.Errorf("invalid duration %q", value))
+	}
+	return parsed
+}
+
+// fail records the first resolution error together with the source of the bad value
+func (r *configResolver) fail(key string, err error) {
+	if r.err == nil {
+		r.err = fmt.Errorf("%s (from %s): %w", key, r.settings[key].Source, err)
+	}
+}
+
+// resolveConfig reads every configuration layer and resolves the Config without validating it
+func resolveConfig(opts LoadOptions) (*Config, error) {
+	r := newEnvResolver()
+	r.flags = opts.Flags
+
+	configFile := opts.ConfigFile
+	if configFile == "" {
+		configFile, _ = r.lookup("SPANWRIGHT_CONFIG")
+	}
+	if configFile != "" {
+		data, err := os.ReadFile(configFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if r.file, err = parseConfigFile(data); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", configFile, err)
+		}
+	} else if data, err := os.ReadFile(DefaultConfigFile); err == nil {
+		if r.file, err = parseConfigFile(data); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", DefaultConfigFile, err)
+		}
+	}
+
+	envFile := opts.EnvFile
+	if envFile == "" {
+		envFile = ".env"
+	}
+	dotenv, err := godotenv.Read(envFile)
+	if err != nil && (opts.EnvFile != "" || !os.IsNotExist(err)) {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+	r.dotenv = dotenv
+
+	config := &Config{
+		ProjectID:            r.get("PROJECT_ID"),
+		InstanceID:           r.get("INSTANCE_ID"),
+		EmulatorHost:         r.get("SPANNER_EMULATOR_HOST"),
+		AllowedEmulatorHosts: r.getList("SPANNER_EMULATOR_ALLOWED_HOSTS"),
+		AllowNonEmulator:     r.get("ALLOW_NON_EMULATOR"),
+		Environment:          r.get("ENVIRONMENT"),
+		Timeout:              r.getInt("TIMEOUT_SECONDS"),
+		ScenariosDir:         r.get("SCENARIOS_DIR"),
+		Retry: RetryPolicy{
+			MaxAttempts:  r.getInt("RETRY_MAX_ATTEMPTS"),
+			InitialDelay: r.getDuration("RETRY_INITIAL_DELAY"),
+			MaxDelay:     r.getDuration("RETRY_MAX_DELAY"),
+			Multiplier:   r.getFloat("RETRY_MULTIPLIER"),
+			Jitter:       r.getFloat("RETRY_JITTER"),
+			MaxElapsed:   r.getDuration("RETRY_MAX_ELAPSED"),
+		},
+	}
+	retryCodes, err := ParseRetryCodes(r.getList("RETRY_CODES"))
+	if err != nil {
+		r.fail("RETRY_CODES", err)
+	}
+	config.Retry.RetryableCodes = retryCodes
+	config.Databases = loadDatabaseSpecs(r)
+	// Resolved for its source only; LoadConfigWithOptions switches to the worker's clones
+	r.get("WORKER_INDEX")
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	// The Spanner client and the emulator guard read the emulator host from the
+	// environment, so a value from a lower layer is exported like godotenv.Load would
+	if source := r.settings["SPANNER_EMULATOR_HOST"].Source; source != "" && source != SourceEnv {
+		os.Setenv("SPANNER_EMULATOR_HOST", config.EmulatorHost)
+	}
+
+	config.settings = r.settings
+	return config, nil
+}
+
+// Settings returns every resolved configuration value with its source, sorted by key
+func (c *Config) Settings() []ConfigSetting {
+	settings := make([]ConfigSetting, 0, len(c.settings))
+	for _, setting := range c.settings {
+		settings = append(settings, setting)
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+	return settings
+}
+
+// Source returns the layer that set a configuration key, or "" when it is unset
+func (c *Config) Source(key string) ConfigSource {
+	return c.settings[key].Source
+}
+
+// configFlag maps a command-line flag onto a configuration key
+type configFlag struct {
+	name  string
+	key   string
+	usage string
+}
+
+var configFlags = []configFlag{
+	{name: "project-id", key: "PROJECT_ID", usage: "Spanner project ID"},
+	{name: "instance-id", key: "INSTANCE_ID", usage: "Spanner instance ID"},
+	{name: "emulator-host", key: "SPANNER_EMULATOR_HOST", usage: "Spanner emulator host:port"},
+	{name: "environment", key: "ENVIRONMENT", usage: "Environment name (development, test, staging)"},
+	{name: "timeout", key: "TIMEOUT_SECONDS", usage: "Operation timeout in seconds"},
+	{name: "scenarios-dir", key: "SCENARIOS_DIR", usage: "Directory containing the scenarios"},
+	{name: "worker", key: "WORKER_INDEX", usage: "Use this worker's clones of the databases (see spanwright workers)"},
+}
+
+// ConfigFlags holds the configuration flags bound to a flag set
+type ConfigFlags struct {
+	configFile *string
+	envFile    *string
+	values     map[string]*string
+}
+
+// BindConfigFlags registers --config, --env-file and the configuration override flags
+func BindConfigFlags(flags *flag.FlagSet) *ConfigFlags {
+	cf := &ConfigFlags{
+		configFile: flags.String("config", "", "Project config file (default: "+DefaultConfigFile+" when present)"),
+		envFile:    flags.String("env-file", "", "Dotenv file (default: .env when present)"),
+		values:     make(map[string]*string),
+	}
+	for _, f := range configFlags {
+		cf.values[f.key] = flags.String(f.name, "", f.usage)
+	}
+	return cf
+}
+
+// Options returns the LoadOptions described by the parsed flags
+func (cf *ConfigFlags) Options() LoadOptions {
+	opts := LoadOptions{
+		ConfigFile: *cf.configFile,
+		EnvFile:    *cf.envFile,
+		Flags:      make(map[string]string),
+	}
+	for key, value := range cf.values {
+		if *value != "" {
+			opts.Flags[key] = *value
+		}
+	}
+	return opts
+}
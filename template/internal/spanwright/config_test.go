@@ -0,0 +1,125 @@
+package spanwright
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeTestFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestResolveConfigPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	configFile := writeTestFile(t, dir, "spanwright.yaml", `
+project_id: file-project
+instance_id: file-instance
+emulator_host: localhost:9010
+environment: test
+timeout_seconds: 60
+retry:
+  max_attempts: 5
+  initial_delay: 250ms
+databases:
+  - name: primary
+    database_id: primary-db
+    schema_path: ./schema
+  - name: audit
+    database_id: audit-db
+    schema_path: ./audit
+    fixture_dir: audit
+`)
+	envFile := writeTestFile(t, dir, ".env", "INSTANCE_ID=dotenv-instance\nTIMEOUT_SECONDS=90\nAUDIT_SCHEMA_PATH=./audit-dotenv\n")
+
+	for _, key := range []string{"PROJECT_ID", "INSTANCE_ID", "SPANNER_EMULATOR_HOST", "ENVIRONMENT", "TIMEOUT_SECONDS", "DATABASES", "DB_COUNT", "SCENARIOS_DIR", "RETRY_MAX_ATTEMPTS", "RETRY_INITIAL_DELAY", "AUDIT_SCHEMA_PATH"} {
+		t.Setenv(key, "")
+	}
+	t.Setenv("TIMEOUT_SECONDS", "100")
+	t.Setenv("ENVIRONMENT", "development")
+	t.Setenv("SPANNER_EMULATOR_HOST", "127.0.0.1:9010")
+
+	config, err := resolveConfig(LoadOptions{
+		ConfigFile: configFile,
+		EnvFile:    envFile,
+		Flags:      map[string]string{"ENVIRONMENT": "staging"},
+	})
+	if err != nil {
+		t.Fatalf("resolveConfig() error = %v", err)
+	}
+
+	checks := []struct {
+		key        string
+		got        interface{}
+		want       interface{}
+		wantSource ConfigSource
+	}{
+		{key: "PROJECT_ID", got: config.ProjectID, want: "file-project", wantSource: SourceFile},
+		{key: "INSTANCE_ID", got: config.InstanceID, want: "dotenv-instance", wantSource: SourceDotEnv},
+		{key: "TIMEOUT_SECONDS", got: config.Timeout, want: 100, wantSource: SourceEnv},
+		{key: "ENVIRONMENT", got: config.Environment, want: "staging", wantSource: SourceFlag},
+		{key: "SCENARIOS_DIR", got: config.ScenariosDir, want: "scenarios", wantSource: SourceDefault},
+		{key: "RETRY_MAX_ATTEMPTS", got: config.Retry.MaxAttempts, want: 5, wantSource: SourceFile},
+		{key: "RETRY_INITIAL_DELAY", got: config.Retry.InitialDelay, want: 250 * time.Millisecond, wantSource: SourceFile},
+		{key: "AUDIT_SCHEMA_PATH", got: config.Databases[1].SchemaPath, want: "./audit-dotenv", wantSource: SourceDotEnv},
+		{key: "PRIMARY_FIXTURE_DIR", got: config.Databases[0].FixtureDir, want: "primary-db", wantSource: SourceDefault},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %v, want %v", c.key, c.got, c.want)
+		}
+		if source := config.Source(c.key); source != c.wantSource {
+			t.Errorf("Source(%s) = %q, want %q", c.key, source, c.wantSource)
+		}
+	}
+
+	if len(config.Databases) != 2 || config.Databases[1].Name != "audit" || config.Databases[1].FixtureDir != "audit" {
+		t.Errorf("Databases = %+v, want primary and audit", config.Databases)
+	}
+}
+
+func TestResolveConfigErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		configYAML string
+		env        map[string]string
+	}{
+		{
+			name:       "unknown field in config file",
+			configYAML: "project_idd: typo\n",
+		},
+		{
+			name:       "database without name",
+			configYAML: "databases:\n  - database_id: primary-db\n",
+		},
+		{
+			name: "malformed integer",
+			env:  map[string]string{"TIMEOUT_SECONDS": "soon"},
+		},
+		{
+			name: "malformed duration",
+			env:  map[string]string{"RETRY_INITIAL_DELAY": "100"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			configFile := writeTestFile(t, dir, "spanwright.yaml", tt.configYAML)
+			envFile := writeTestFile(t, dir, ".env", "")
+			for _, key := range []string{"TIMEOUT_SECONDS", "RETRY_INITIAL_DELAY"} {
+				t.Setenv(key, tt.env[key])
+			}
+
+			if _, err := resolveConfig(LoadOptions{ConfigFile: configFile, EnvFile: envFile}); err == nil {
+				t.Error("resolveConfig() expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package spanwright
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"google.golang.org/grpc/codes"
+)
+
+// Exit codes used by the command-line tools
+const (
+	ExitFailure     = 1
+	ExitTimeout     = 124
+	ExitInterrupted = 130
+)
+
+// ErrInterrupted is the cancellation cause of a SignalContext that received a signal
+var ErrInterrupted = errors.New("interrupted")
+
+// TimeoutDuration returns TIMEOUT_SECONDS as a duration
+func (c *Config) TimeoutDuration() time.Duration {
+	return time.Duration(c.Timeout) * time.Second
+}
+
+// WithTimeout returns a context bounded by the configured timeout
+func (c *Config) WithTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.TimeoutDuration())
+}
+
+// operationContext bounds a single DatabaseManager operation by the configured timeout.
+// An earlier deadline already set on ctx still applies.
+func (dm *DatabaseManager) operationContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if dm.config.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, dm.config.Timeout)
+}
+
+// SignalContext returns a context that is cancelled with ErrInterrupted on SIGINT or
+// SIGTERM. A second signal is left to the default handler so it kills the process.
+func SignalContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case sig := <-signals:
+			signal.Stop(signals)
+			cancel(fmt.Errorf("%w by %v", ErrInterrupted, sig))
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel(context.Canceled)
+	}
+}
+
+// ExitCode maps the error of an operation run under a SignalContext to a process exit code
+func ExitCode(ctx context.Context, err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(context.Cause(ctx), ErrInterrupted):
+		return ExitInterrupted
+	case errors.Is(err, context.DeadlineExceeded) || spanner.ErrCode(err) == codes.DeadlineExceeded:
+		return ExitTimeout
+	default:
+		return ExitFailure
+	}
+}
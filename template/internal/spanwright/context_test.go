@@ -0,0 +1,88 @@
+package spanwright
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestExitCode(t *testing.T) {
+	interrupted, cancel := context.WithCancelCause(context.Background())
+	cancel(fmt.Errorf("%w by interrupt", ErrInterrupted))
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want int
+	}{
+		{name: "success", ctx: context.Background(), err: nil, want: 0},
+		{name: "failure", ctx: context.Background(), err: errors.New("boom"), want: ExitFailure},
+		{name: "context deadline", ctx: context.Background(), err: fmt.Errorf("seed: %w", context.DeadlineExceeded), want: ExitTimeout},
+		{name: "spanner deadline", ctx: context.Background(), err: status.Error(codes.DeadlineExceeded, "deadline"), want: ExitTimeout},
+		{name: "interrupted", ctx: interrupted, err: context.Canceled, want: ExitInterrupted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.ctx, tt.err); got != tt.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithRetryHonorsContext(t *testing.T) {
+	t.Run("cancelled before the first attempt", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		calls := 0
+		err := WithRetry(ctx, "test", func(ctx context.Context, attempt int) error {
+			calls++
+			return nil
+		})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("WithRetry() error = %v, want context.Canceled", err)
+		}
+		if calls != 0 {
+			t.Errorf("WithRetry() called fn %d times, want 0", calls)
+		}
+	})
+
+	t.Run("deadline during backoff", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		calls := 0
+		err := RetryPolicy{MaxAttempts: 3, InitialDelay: time.Second}.Do(ctx, "test", func(ctx context.Context, attempt int) error {
+			calls++
+			return status.Error(codes.Unavailable, "unavailable")
+		})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Do() error = %v, want context.DeadlineExceeded", err)
+		}
+		if calls != 1 {
+			t.Errorf("Do() called fn %d times, want 1", calls)
+		}
+	})
+
+	t.Run("deadline error is not retried", func(t *testing.T) {
+		calls := 0
+		err := RetryPolicy{MaxAttempts: 3}.Do(context.Background(), "test", func(ctx context.Context, attempt int) error {
+			calls++
+			return fmt.Errorf("query: %w", context.DeadlineExceeded)
+		})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Do() error = %v, want context.DeadlineExceeded", err)
+		}
+		if calls != 1 {
+			t.Errorf("Do() called fn %d times, want 1", calls)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package spanwright
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// databaseNameRegex restricts logical database names to what can be turned into an env prefix
+var databaseNameRegex = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// DatabaseSpec describes one database used by the scenarios
+type DatabaseSpec struct {
+	// Name is the logical name, e.g. "primary"; it selects the <NAME>_* env variables
+	Name       string
+	DatabaseID string
+	SchemaPath string
+	// FixtureDir is the sub-directory of scenarios/<scenario>/fixtures holding its fixtures
+	FixtureDir string
+	// ExpectedFile is the expected-state file inside scenarios/<scenario>
+	ExpectedFile string
+	// Dialect is the dialect the database is created with
+	Dialect Dialect
+}
+
+// FixturePath returns the fixture directory of the database for a scenario directory
+func (d DatabaseSpec) FixturePath(scenarioDir string) string {
+	return filepath.Join(scenarioDir, "fixtures", d.FixtureDir)
+}
+
+// ExpectedPath returns the expected-state file of the database for a scenario directory
+func (d DatabaseSpec) ExpectedPath(scenarioDir string) string {
+	return filepath.Join(scenarioDir, d.ExpectedFile)
+}
+
+// SnapshotPath returns the file a named snapshot of the database is kept in; each worker
+// clone has its own
+func (d DatabaseSpec) SnapshotPath(snapshotDir, name string) string {
+	return filepath.Join(snapshotDir, name, d.DatabaseID+".yaml")
+}
+
+// SeededPath returns the file the keys of the rows seeded into the database are recorded in
+func (d DatabaseSpec) SeededPath(seededDir string) string {
+	return filepath.Join(seededDir, d.DatabaseID+".yaml")
+}
+
+// databaseEnvKey returns the env variable holding a setting of the named database
+func databaseEnvKey(name, setting string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_" + setting
+}
+
+// databaseNames returns the logical database names listed in DATABASES. Without it the
+// legacy layout applies: "primary", plus "secondary" when DB_COUNT is 2.
+func databaseNames(r *configResolver) []string {
+	if names := r.getList("DATABASES"); len(names) > 0 {
+		return names
+	}
+
+	names := []string{"primary"}
+	if count := r.getInt("DB_COUNT"); count >= 2 {
+		names = append(names, "secondary")
+	}
+	return names
+}
+
+// loadDatabaseSpecs resolves the <NAME>_DATABASE_ID, <NAME>_SCHEMA_PATH, <NAME>_FIXTURE_DIR,
+// <NAME>_EXPECTED_FILE and <NAME>_DIALECT settings of every database listed in DATABASES
+func loadDatabaseSpecs(r *configResolver) []DatabaseSpec {
+	var specs []DatabaseSpec
+	for _, name := range databaseNames(r) {
+		name = strings.ToLower(name)
+		databaseID := r.get(databaseEnvKey(name, "DATABASE_ID"))
+
+		dialectKey := databaseEnvKey(name, "DIALECT")
+		dialect, err := ParseDialect(r.get(dialectKey))
+		if err != nil {
+			r.fail(dialectKey, err)
+		}
+
+		specs = append(specs, DatabaseSpec{
+			Name:         name,
+			DatabaseID:   databaseID,
+			SchemaPath:   r.get(databaseEnvKey(name, "SCHEMA_PATH")),
+			FixtureDir:   r.getWithDefault(databaseEnvKey(name, "FIXTURE_DIR"), databaseID),
+			ExpectedFile: r.getWithDefault(databaseEnvKey(name, "EXPECTED_FILE"), "expected-"+name+".yaml"),
+			Dialect:      dialect,
+		})
+	}
+	return specs
+}
+
+// validateDatabaseSpecs checks that names and IDs are valid and unique and that every
+// database has a schema
+func validateDatabaseSpecs(specs []DatabaseSpec) error {
+	if len(specs) == 0 {
+		return fmt.Errorf("at least one database must be configured in DATABASES")
+	}
+
+	names := make(map[string]bool)
+	ids := make(map[string]string)
+	for _, spec := range specs {
+		if !databaseNameRegex.MatchString(spec.Name) {
+			return fmt.Errorf("database name %q must start with a lowercase letter and contain only lowercase letters, digits, underscores, and hyphens", spec.Name)
+		}
+		if names[spec.Name] {
+			return fmt.Errorf("database %q is listed more than once", spec.Name)
+		}
+		names[spec.Name] = true
+
+		idKey := databaseEnvKey(spec.Name, "DATABASE_ID")
+		if spec.DatabaseID == "" {
+			return fmt.Errorf("%s is required", idKey)
+		}
+		if err := ValidateDatabaseID(spec.DatabaseID); err != nil {
+			return fmt.Errorf("%s: %w", idKey, err)
+		}
+		if other, ok := ids[spec.DatabaseID]; ok {
+			return fmt.Errorf("%s: database ID %q is already used by %q", idKey, spec.DatabaseID, other)
+		}
+		ids[spec.DatabaseID] = spec.Name
+
+		if spec.SchemaPath == "" {
+			return fmt.Errorf("%s is required", databaseEnvKey(spec.Name, "SCHEMA_PATH"))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package spanwright
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLoadDatabaseSpecs(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		want []DatabaseSpec
+	}{
+		{
+			name: "legacy single database",
+			env: map[string]string{
+				"PRIMARY_DATABASE_ID": "primary-db",
+				"PRIMARY_SCHEMA_PATH": "./schema",
+			},
+			want: []DatabaseSpec{
+				{Name: "primary", DatabaseID: "primary-db", SchemaPath: "./schema", FixtureDir: "primary-db", ExpectedFile: "expected-primary.yaml", Dialect: DialectGoogleSQL},
+			},
+		},
+		{
+			name: "legacy two databases",
+			env: map[string]string{
+				"DB_COUNT":              "2",
+				"PRIMARY_DATABASE_ID":   "primary-db",
+				"PRIMARY_SCHEMA_PATH":   "./schema",
+				"SECONDARY_DATABASE_ID": "secondary-db",
+				"SECONDARY_SCHEMA_PATH": "./schema2",
+			},
+			want: []DatabaseSpec{
+				{Name: "primary", DatabaseID: "primary-db", SchemaPath: "./schema", FixtureDir: "primary-db", ExpectedFile: "expected-primary.yaml", Dialect: DialectGoogleSQL},
+				{Name: "secondary", DatabaseID: "secondary-db", SchemaPath: "./schema2", FixtureDir: "secondary-db", ExpectedFile: "expected-secondary.yaml", Dialect: DialectGoogleSQL},
+			},
+		},
+		{
+			name: "named list with overrides",
+			env: map[string]string{
+				"DATABASES":               "primary, audit-log",
+				"PRIMARY_DATABASE_ID":     "primary-db",
+				"PRIMARY_SCHEMA_PATH":     "./schema",
+				"AUDIT_LOG_DATABASE_ID":   "audit-db",
+				"AUDIT_LOG_SCHEMA_PATH":   "./audit",
+				"AUDIT_LOG_FIXTURE_DIR":   "audit",
+				"AUDIT_LOG_EXPECTED_FILE": "expected-audit.yaml",
+				"AUDIT_LOG_DIALECT":       "postgresql",
+				"SECONDARY_DATABASE_ID":   "ignored-db",
+			},
+			want: []DatabaseSpec{
+				{Name: "primary", DatabaseID: "primary-db", SchemaPath: "./schema", FixtureDir: "primary-db", ExpectedFile: "expected-primary.yaml", Dialect: DialectGoogleSQL},
+				{Name: "audit-log", DatabaseID: "audit-db", SchemaPath: "./audit", FixtureDir: "audit", ExpectedFile: "expected-audit.yaml", Dialect: DialectPostgreSQL},
+			},
+		},
+	}
+
+	keys := []string{
+		"DATABASES", "DB_COUNT",
+		"PRIMARY_DATABASE_ID", "PRIMARY_SCHEMA_PATH",
+		"SECONDARY_DATABASE_ID", "SECONDARY_SCHEMA_PATH",
+		"AUDIT_LOG_DATABASE_ID", "AUDIT_LOG_SCHEMA_PATH", "AUDIT_LOG_FIXTURE_DIR", "AUDIT_LOG_EXPECTED_FILE", "AUDIT_LOG_DIALECT",
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range keys {
+				t.Setenv(key, tt.env[key])
+			}
+
+			got := loadDatabaseSpecs(newEnvResolver())
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadDatabaseSpecs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateDatabaseSpecs(t *testing.T) {
+	valid := DatabaseSpec{Name: "primary", DatabaseID: "primary-db", SchemaPath: "./schema"}
+
+	tests := []struct {
+		name    string
+		specs   []DatabaseSpec
+		wantErr bool
+	}{
+		{
+			name:  "valid databases",
+			specs: []DatabaseSpec{valid, {Name: "audit", DatabaseID: "audit-db", SchemaPath: "./audit"}},
+		},
+		{
+			name:    "no databases",
+			specs:   nil,
+			wantErr: true,
+		},
+		{
+			name:    "missing database ID",
+			specs:   []DatabaseSpec{{Name: "primary", SchemaPath: "./schema"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid database ID",
+			specs:   []DatabaseSpec{{Name: "primary", DatabaseID: "Primary_DB", SchemaPath: "./schema"}},
+			wantErr: true,
+		},
+		{
+			name:    "missing schema path",
+			specs:   []DatabaseSpec{{Name: "primary", DatabaseID: "primary-db"}},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate name",
+			specs:   []DatabaseSpec{valid, {Name: "primary", DatabaseID: "other-db", SchemaPath: "./schema"}},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate database ID",
+			specs:   []DatabaseSpec{valid, {Name: "audit", DatabaseID: "primary-db", SchemaPath: "./audit"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid name",
+			specs:   []DatabaseSpec{{Name: "1st", DatabaseID: "primary-db", SchemaPath: "./schema"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDatabaseSpecs(tt.specs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateDatabaseSpecs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigDatabase(t *testing.T) {
+	config := &Config{
+		ProjectID:  "test-project",
+		InstanceID: "test-instance",
+		Databases: []DatabaseSpec{
+			{Name: "primary", DatabaseID: "primary-db"},
+			{Name: "secondary", DatabaseID: "secondary-db"},
+		},
+	}
+
+	for _, key := range []string{"secondary", "secondary-db"} {
+		dbConfig, err := config.GetDatabaseConfig(key)
+		if err != nil {
+			t.Fatalf("GetDatabaseConfig(%q) error = %v", key, err)
+		}
+		if dbConfig.DatabaseID != "secondary-db" {
+			t.Errorf("GetDatabaseConfig(%q).DatabaseID = %q, want %q", key, dbConfig.DatabaseID, "secondary-db")
+		}
+	}
+
+	if _, err := config.GetDatabaseConfig("unknown"); err == nil {
+		t.Error("GetDatabaseConfig(\"unknown\") expected error")
+	}
+}
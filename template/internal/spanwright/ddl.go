@@ -0,0 +1,202 @@
+package spanwright
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DDLStatement is a single DDL statement together with where it was read from
+type DDLStatement struct {
+	SQL  string
+	File string
+	Line int
+}
+
+// Location returns the file:line the statement starts at
+func (s DDLStatement) Location() string {
+	return fmt.Sprintf("%s:%d", s.File, s.Line)
+}
+
+// SplitDDL splits DDL text into statements on semicolons. Semicolons inside comments and
+// quoted strings or identifiers are ignored, following the lexical rules of the dialect:
+// GoogleSQL has --, # and /* */ comments, single, double and triple quoted strings and
+// backtick-quoted identifiers; PostgreSQL has -- and /* */ comments, single-quoted strings,
+// double-quoted identifiers and dollar-quoted strings. Comments are stripped from the result.
+func SplitDDL(file, content string, dialect Dialect) ([]DDLStatement, error) {
+	pg := dialect.IsPostgreSQL()
+
+	var statements []DDLStatement
+	var current strings.Builder
+	line := 1
+	startLine := 0
+
+	flush := func() {
+		if sql := strings.TrimSpace(current.String()); sql != "" {
+			statements = append(statements, DDLStatement{SQL: sql, File: file, Line: startLine})
+		}
+		current.Reset()
+		startLine = 0
+	}
+
+	// write copies text into the current statement, remembering where it starts
+	write := func(text string) {
+		if startLine == 0 && strings.TrimSpace(text) != "" {
+			startLine = line
+		}
+		current.WriteString(text)
+	}
+
+	for i := 0; i < len(content); {
+		c := content[i]
+
+		switch {
+		case c == '\n':
+			current.WriteByte(c)
+			line++
+			i++
+
+		case (c == '#' && !pg) || (c == '-' && strings.HasPrefix(content[i:], "--")):
+			end := strings.IndexByte(content[i:], '\n')
+			if end < 0 {
+				end = len(content) - i
+			}
+			// Keep a separator so tokens on either side of the comment stay apart
+			current.WriteByte(' ')
+			i += end
+
+		case c == '/' && strings.HasPrefix(content[i:], "/*"):
+			end := strings.Index(content[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("%s:%d: unterminated block comment", file, line)
+			}
+			comment := content[i : i+2+end+2]
+			line += strings.Count(comment, "\n")
+			current.WriteByte(' ')
+			i += len(comment)
+
+		case c == '\'' || c == '"' || (c == '`' && !pg) || (c == '$' && pg && startsDollarQuote(content, i)):
+			n, err := quotedTokenLength(content, i, pg)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", file, line, err)
+			}
+			quoted := content[i : i+n]
+			write(quoted)
+			line += strings.Count(quoted, "\n")
+			i += n
+
+		case c == ';':
+			flush()
+			i++
+
+		default:
+			write(content[i : i+1])
+			i++
+		}
+	}
+	flush()
+
+	return statements, nil
+}
+
+// quotedTokenLength returns the length of the quoted token starting at content[i]
+func quotedTokenLength(content string, i int, pg bool) (int, error) {
+	switch {
+	case !pg:
+		return quotedLength(content[i:])
+	case content[i] == '$':
+		return dollarQuotedLength(content[i:])
+	}
+
+	// Only E'...' strings treat backslash as an escape
+	escapes := content[i] == '\'' && i > 0 && (content[i-1] == 'E' || content[i-1] == 'e') &&
+		(i == 1 || !isIdentifierByte(content[i-2]))
+	return pgQuotedLength(content[i:], escapes)
+}
+
+// quotedLength returns the length of the quoted token at the start of s, including
+// its delimiters. Backslash escapes the next character.
+func quotedLength(s string) (int, error) {
+	quote := s[:1]
+	kind := "string literal"
+	if quote == "`" {
+		kind = "quoted identifier"
+	} else if strings.HasPrefix(s, quote+quote+quote) {
+		quote = s[:3]
+	}
+
+	for i := len(quote); i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case s[i] == '\n' && len(quote) == 1 && quote != "`":
+			return 0, fmt.Errorf("unterminated %s", kind)
+		case strings.HasPrefix(s[i:], quote):
+			return i + len(quote), nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated %s", kind)
+}
+
+// pgQuotedLength returns the length of the PostgreSQL string literal or quoted identifier at
+// the start of s. A doubled quote stands for itself; backslash escapes the next character
+// only when escapes is set.
+func pgQuotedLength(s string, escapes bool) (int, error) {
+	quote := s[0]
+	kind := "string literal"
+	if quote == '"' {
+		kind = "quoted identifier"
+	}
+
+	for i := 1; i < len(s); i++ {
+		switch {
+		case escapes && s[i] == '\\':
+			i++
+		case s[i] == quote && i+1 < len(s) && s[i+1] == quote:
+			i++
+		case s[i] == quote:
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated %s", kind)
+}
+
+// startsDollarQuote reports whether a dollar-quoted string starts at content[i]. A $ inside
+// an identifier or before a digit, as in a $1 parameter, does not start one.
+func startsDollarQuote(content string, i int) bool {
+	if i > 0 && (isIdentifierByte(content[i-1]) || content[i-1] == '$') {
+		return false
+	}
+	return dollarTag(content[i:]) != ""
+}
+
+// dollarTag returns the opening $tag$ of a PostgreSQL dollar-quoted string at the start of
+// s, or "" when s does not start one (for example a $1 parameter)
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '$':
+			return s[:i+1]
+		case i == 1 && s[i] >= '0' && s[i] <= '9':
+			return ""
+		case !isIdentifierByte(s[i]):
+			return ""
+		}
+	}
+	return ""
+}
+
+// dollarQuotedLength returns the length of the dollar-quoted string at the start of s,
+// including both tags
+func dollarQuotedLength(s string) (int, error) {
+	tag := dollarTag(s)
+	end := strings.Index(s[len(tag):], tag)
+	if end < 0 {
+		return 0, fmt.Errorf("unterminated dollar-quoted string %s", tag)
+	}
+	return len(tag) + end + len(tag), nil
+}
+
+// isIdentifierByte reports whether b can appear in an unquoted identifier
+func isIdentifierByte(b byte) bool {
+	return b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
@@ -0,0 +1,140 @@
+package spanwright
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitDDL(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []DDLStatement
+		wantErr bool
+	}{
+		{
+			name:    "empty file",
+			content: "",
+			want:    nil,
+		},
+		{
+			name:    "single statement without trailing semicolon",
+			content: "CREATE TABLE Users (ID STRING(36)) PRIMARY KEY (ID)",
+			want: []DDLStatement{
+				{SQL: "CREATE TABLE Users (ID STRING(36)) PRIMARY KEY (ID)", File: "schema.sql", Line: 1},
+			},
+		},
+		{
+			name:    "multiple statements with line numbers",
+			content: "CREATE TABLE A (ID INT64) PRIMARY KEY (ID);\n\nCREATE TABLE B (\n  ID INT64\n) PRIMARY KEY (ID);\n",
+			want: []DDLStatement{
+				{SQL: "CREATE TABLE A (ID INT64) PRIMARY KEY (ID)", File: "schema.sql", Line: 1},
+				{SQL: "CREATE TABLE B (\n  ID INT64\n) PRIMARY KEY (ID)", File: "schema.sql", Line: 3},
+			},
+		},
+		{
+			name:    "comments are stripped",
+			content: "-- header; with semicolon\n# another; comment\n/* block;\ncomment */\nCREATE INDEX Idx ON A (ID); -- trailing\n",
+			want: []DDLStatement{
+				{SQL: "CREATE INDEX Idx ON A (ID)", File: "schema.sql", Line: 5},
+			},
+		},
+		{
+			name:    "semicolons inside strings",
+			content: "ALTER TABLE A ADD COLUMN S STRING(MAX) DEFAULT ('a;b');ALTER TABLE A ADD COLUMN T STRING(MAX) DEFAULT (\"c;d\")",
+			want: []DDLStatement{
+				{SQL: "ALTER TABLE A ADD COLUMN S STRING(MAX) DEFAULT ('a;b')", File: "schema.sql", Line: 1},
+				{SQL: "ALTER TABLE A ADD COLUMN T STRING(MAX) DEFAULT (\"c;d\")", File: "schema.sql", Line: 1},
+			},
+		},
+		{
+			name:    "escaped quote inside string",
+			content: `CREATE VIEW V SQL SECURITY INVOKER AS SELECT 'it\'s; fine' AS S; SELECT 1`,
+			want: []DDLStatement{
+				{SQL: `CREATE VIEW V SQL SECURITY INVOKER AS SELECT 'it\'s; fine' AS S`, File: "schema.sql", Line: 1},
+				{SQL: "SELECT 1", File: "schema.sql", Line: 1},
+			},
+		},
+		{
+			name:    "triple quoted string spanning lines",
+			content: "ALTER TABLE A ADD COLUMN S STRING(MAX) DEFAULT ('''x;\n-- not a comment\ny''');\nDROP TABLE B;",
+			want: []DDLStatement{
+				{SQL: "ALTER TABLE A ADD COLUMN S STRING(MAX) DEFAULT ('''x;\n-- not a comment\ny''')", File: "schema.sql", Line: 1},
+				{SQL: "DROP TABLE B", File: "schema.sql", Line: 4},
+			},
+		},
+		{
+			name:    "backtick identifiers",
+			content: "CREATE TABLE `Order;s` (ID INT64) PRIMARY KEY (ID);",
+			want: []DDLStatement{
+				{SQL: "CREATE TABLE `Order;s` (ID INT64) PRIMARY KEY (ID)", File: "schema.sql", Line: 1},
+			},
+		},
+		{
+			name:    "unterminated string",
+			content: "CREATE TABLE A (ID INT64);\nALTER TABLE A ADD COLUMN S STRING(MAX) DEFAULT ('oops);",
+			wantErr: true,
+		},
+		{
+			name:    "newline inside single quoted string",
+			content: "SELECT 'a\nb'",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated block comment",
+			content: "/* never closed\nCREATE TABLE A (ID INT64) PRIMARY KEY (ID);",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SplitDDL("schema.sql", tt.content, DialectGoogleSQL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SplitDDL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitDDL() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitDDLErrorLocation(t *testing.T) {
+	_, err := SplitDDL("schema.sql", "CREATE TABLE A (ID INT64);\n\nSELECT \"open", DialectGoogleSQL)
+	if err == nil {
+		t.Fatal("SplitDDL() expected error")
+	}
+	if want := "schema.sql:3: unterminated string literal"; err.Error() != want {
+		t.Errorf("SplitDDL() error = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestSplitDDLPostgreSQL(t *testing.T) {
+	content := "-- users\n" +
+		"CREATE TABLE users (id bigint PRIMARY KEY, note text DEFAULT 'it''s; fine', path text DEFAULT E'a\\\\'';b');\n" +
+		"# not a comment\n" +
+		"CREATE TABLE \"odd;name\" (id bigint PRIMARY KEY, `x` bigint);\n" +
+		"CREATE VIEW v SQL SECURITY INVOKER AS SELECT $tag$a;b$tag$ AS s, price$1 FROM users;\n"
+
+	want := []DDLStatement{
+		{SQL: "CREATE TABLE users (id bigint PRIMARY KEY, note text DEFAULT 'it''s; fine', path text DEFAULT E'a\\\\'';b')", File: "schema.sql", Line: 2},
+		{SQL: "# not a comment\nCREATE TABLE \"odd;name\" (id bigint PRIMARY KEY, `x` bigint)", File: "schema.sql", Line: 3},
+		{SQL: "CREATE VIEW v SQL SECURITY INVOKER AS SELECT $tag$a;b$tag$ AS s, price$1 FROM users", File: "schema.sql", Line: 5},
+	}
+
+	got, err := SplitDDL("schema.sql", content, DialectPostgreSQL)
+	if err != nil {
+		t.Fatalf("SplitDDL() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitDDL() = %#v, want %#v", got, want)
+	}
+
+	if _, err := SplitDDL("schema.sql", "SELECT $$open;", DialectPostgreSQL); err == nil {
+		t.Error("SplitDDL() expected an unterminated dollar-quote error")
+	}
+}
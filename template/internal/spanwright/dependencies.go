@@ -0,0 +1,102 @@
+package spanwright
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DependencyCycleError is returned when tables depend on each other in a cycle
+type DependencyCycleError struct {
+	Cycle []string
+}
+
+func (e *DependencyCycleError) Error() string {
+	return fmt.Sprintf("dependency cycle detected between tables: %s", strings.Join(e.Cycle, " -> "))
+}
+
+// SortTablesByDependency orders tables so that every table comes after the tables it
+// depends on. Dependencies on tables outside the list and self-references are ignored;
+// ties are broken alphabetically so the order is stable.
+func SortTablesByDependency(tables []string, dependencies map[string][]string) ([]string, error) {
+	included := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		included[table] = true
+	}
+
+	pending := make(map[string]int, len(tables))
+	children := make(map[string][]string)
+	for table := range included {
+		pending[table] = 0
+		seen := make(map[string]bool)
+		for _, parent := range dependencies[table] {
+			if parent == table || !included[parent] || seen[parent] {
+				continue
+			}
+			seen[parent] = true
+			pending[table]++
+			children[parent] = append(children[parent], table)
+		}
+	}
+
+	var ready []string
+	for table, count := range pending {
+		if count == 0 {
+			ready = append(ready, table)
+		}
+	}
+	sort.Strings(ready)
+
+	sorted := make([]string, 0, len(pending))
+	for len(ready) > 0 {
+		table := ready[0]
+		ready = ready[1:]
+		sorted = append(sorted, table)
+
+		for _, child := range children[table] {
+			pending[child]--
+			if pending[child] == 0 {
+				ready = append(ready, child)
+			}
+		}
+		sort.Strings(ready)
+	}
+
+	if len(sorted) < len(pending) {
+		return nil, &DependencyCycleError{Cycle: findCycle(pending, dependencies)}
+	}
+	return sorted, nil
+}
+
+// findCycle returns one dependency cycle among the tables that could not be sorted
+func findCycle(pending map[string]int, dependencies map[string][]string) []string {
+	var remaining []string
+	for table, count := range pending {
+		if count > 0 {
+			remaining = append(remaining, table)
+		}
+	}
+	sort.Strings(remaining)
+
+	// Every unsorted table still waits on another unsorted table, so walking parents
+	// from any of them must eventually revisit a table
+	position := make(map[string]int)
+	var path []string
+	table := remaining[0]
+	for {
+		if i, ok := position[table]; ok {
+			return append(path[i:], table)
+		}
+		position[table] = len(path)
+		path = append(path, table)
+
+		parents := append([]string(nil), dependencies[table]...)
+		sort.Strings(parents)
+		for _, parent := range parents {
+			if parent != table && pending[parent] > 0 {
+				table = parent
+				break
+			}
+		}
+	}
+}
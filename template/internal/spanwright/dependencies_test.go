@@ -0,0 +1,88 @@
+package spanwright
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSortTablesByDependency(t *testing.T) {
+	tests := []struct {
+		name         string
+		tables       []string
+		dependencies map[string][]string
+		want         []string
+	}{
+		{
+			name:   "no dependencies sorts alphabetically",
+			tables: []string{"Users", "Products", "Analytics"},
+			want:   []string{"Analytics", "Products", "Users"},
+		},
+		{
+			name:   "interleaved child after parent",
+			tables: []string{"Albums", "Songs", "Singers"},
+			dependencies: map[string][]string{
+				"Albums": {"Singers"},
+				"Songs":  {"Albums"},
+			},
+			want: []string{"Singers", "Albums", "Songs"},
+		},
+		{
+			name:   "foreign key and interleave on the same table",
+			tables: []string{"OrderItems", "Orders", "Products", "Users"},
+			dependencies: map[string][]string{
+				"Orders":     {"Users"},
+				"OrderItems": {"Orders", "Products"},
+			},
+			want: []string{"Products", "Users", "Orders", "OrderItems"},
+		},
+		{
+			name:   "dependencies outside the list are ignored",
+			tables: []string{"Orders"},
+			dependencies: map[string][]string{
+				"Orders": {"Users"},
+			},
+			want: []string{"Orders"},
+		},
+		{
+			name:   "self reference is ignored",
+			tables: []string{"Employees"},
+			dependencies: map[string][]string{
+				"Employees": {"Employees"},
+			},
+			want: []string{"Employees"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SortTablesByDependency(tt.tables, tt.dependencies)
+			if err != nil {
+				t.Fatalf("SortTablesByDependency() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SortTablesByDependency() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortTablesByDependencyCycle(t *testing.T) {
+	dependencies := map[string][]string{
+		"A": {"B"},
+		"B": {"C"},
+		"C": {"A"},
+		"D": {"A"},
+	}
+
+	_, err := SortTablesByDependency([]string{"A", "B", "C", "D"}, dependencies)
+
+	var cycleErr *DependencyCycleError
+	if !errors.As(err, &cycleErr) {
+		t.Fatalf("SortTablesByDependency() error = %v, want DependencyCycleError", err)
+	}
+	want := []string{"A", "B", "C", "A"}
+	if !reflect.DeepEqual(cycleErr.Cycle, want) {
+		t.Errorf("DependencyCycleError.Cycle = %v, want %v", cycleErr.Cycle, want)
+	}
+}
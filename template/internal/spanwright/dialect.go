@@ -0,0 +1,147 @@
+package spanwright
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+)
+
+// Dialect is the SQL dialect of a Spanner database
+type Dialect string
+
+const (
+	DialectGoogleSQL  Dialect = "GOOGLE_STANDARD_SQL"
+	DialectPostgreSQL Dialect = "POSTGRESQL"
+)
+
+// ParseDialect parses a dialect name; the empty string means GoogleSQL
+func ParseDialect(name string) (Dialect, error) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "", "GOOGLESQL", "GOOGLE_STANDARD_SQL":
+		return DialectGoogleSQL, nil
+	case "POSTGRESQL", "POSTGRES", "PG":
+		return DialectPostgreSQL, nil
+	}
+	return "", fmt.Errorf("unknown database dialect %q (expected GOOGLE_STANDARD_SQL or POSTGRESQL)", name)
+}
+
+// IsPostgreSQL reports whether d is the PostgreSQL dialect
+func (d Dialect) IsPostgreSQL() bool {
+	return d == DialectPostgreSQL
+}
+
+// QuoteIdentifier quotes a possibly schema-qualified identifier: with backticks for
+// GoogleSQL and double quotes for PostgreSQL
+func (d Dialect) QuoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if d.IsPostgreSQL() {
+			parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+		} else {
+			parts[i] = "`" + strings.ReplaceAll(part, "`", "``") + "`"
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// Placeholder returns the SQL placeholder of the query parameter at position (starting at
+// 1): @p1 for GoogleSQL and $1 for PostgreSQL. Both are bound from the "p1" parameter.
+func (d Dialect) Placeholder(position int) string {
+	if d.IsPostgreSQL() {
+		return fmt.Sprintf("$%d", position)
+	}
+	return fmt.Sprintf("@p%d", position)
+}
+
+// Statement builds a statement whose SQL refers to args through Placeholder
+func (d Dialect) Statement(sql string, args ...interface{}) spanner.Statement {
+	params := make(map[string]interface{}, len(args))
+	for i, arg := range args {
+		params[fmt.Sprintf("p%d", i+1)] = arg
+	}
+	return spanner.Statement{SQL: sql, Params: params}
+}
+
+// DefaultSchema returns the name information_schema uses for the default schema
+func (d Dialect) DefaultSchema() string {
+	if d.IsPostgreSQL() {
+		return "public"
+	}
+	return ""
+}
+
+// systemSchemas returns the catalog's own schemas as a SQL list, to filter them out of
+// information_schema queries
+func (d Dialect) systemSchemas() string {
+	if d.IsPostgreSQL() {
+		return `('information_schema', 'spanner_sys', 'pg_catalog')`
+	}
+	return `('INFORMATION_SCHEMA', 'SPANNER_SYS')`
+}
+
+// isTrue returns a boolean expression for an information_schema flag column, which is a
+// BOOL in GoogleSQL and a YES/NO string in PostgreSQL
+func (d Dialect) isTrue(column string) string {
+	if d.IsPostgreSQL() {
+		return "(" + column + " = 'YES')"
+	}
+	return column
+}
+
+// CreateDatabaseStatement returns the CREATE DATABASE statement for a database ID
+func (d Dialect) CreateDatabaseStatement(databaseID string) string {
+	return "CREATE DATABASE " + d.QuoteIdentifier(databaseID)
+}
+
+// databaseDialect returns the admin API value of the dialect
+func (d Dialect) databaseDialect() databasepb.DatabaseDialect {
+	if d.IsPostgreSQL() {
+		return databasepb.DatabaseDialect_POSTGRESQL
+	}
+	return databasepb.DatabaseDialect_GOOGLE_STANDARD_SQL
+}
+
+// dialectFromDatabase converts the admin API value of a dialect
+func dialectFromDatabase(dialect databasepb.DatabaseDialect) Dialect {
+	if dialect == databasepb.DatabaseDialect_POSTGRESQL {
+		return DialectPostgreSQL
+	}
+	return DialectGoogleSQL
+}
+
+// Dialect returns the dialect of the database, reading it from information_schema the
+// first time it is needed
+func (dm *DatabaseManager) Dialect(ctx context.Context) (Dialect, error) {
+	dm.dialectMu.Lock()
+	defer dm.dialectMu.Unlock()
+
+	if dm.dialect != "" {
+		return dm.dialect, nil
+	}
+
+	// The query is valid in both dialects
+	stmt := spanner.NewStatement(`SELECT option_value FROM information_schema.database_options
+		WHERE option_name = 'database_dialect'`)
+	value, err := QueryOne[string](ctx, dm, stmt)
+	if err != nil && !errors.Is(err, ErrNoRows) {
+		return "", fmt.Errorf("failed to detect the dialect of %s: %w", dm.config.DatabaseID, err)
+	}
+
+	dialect, err := ParseDialect(value)
+	if err != nil {
+		return "", err
+	}
+	dm.dialect = dialect
+	return dialect, nil
+}
+
+// setDialect records a dialect learned elsewhere, such as from the admin API
+func (dm *DatabaseManager) setDialect(dialect Dialect) {
+	dm.dialectMu.Lock()
+	defer dm.dialectMu.Unlock()
+	dm.dialect = dialect
+}
@@ -0,0 +1,78 @@
+package spanwright
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDialect(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Dialect
+		wantErr bool
+	}{
+		{name: "", want: DialectGoogleSQL},
+		{name: "googlesql", want: DialectGoogleSQL},
+		{name: "GOOGLE_STANDARD_SQL", want: DialectGoogleSQL},
+		{name: "postgresql", want: DialectPostgreSQL},
+		{name: " pg ", want: DialectPostgreSQL},
+		{name: "mysql", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDialect(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDialect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDialect() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDialectSQL(t *testing.T) {
+	tests := []struct {
+		dialect     Dialect
+		quoted      string
+		placeholder string
+		create      string
+	}{
+		{
+			dialect:     DialectGoogleSQL,
+			quoted:      "`sales`.`Order``s`",
+			placeholder: "@p2",
+			create:      "CREATE DATABASE `test-db`",
+		},
+		{
+			dialect:     DialectPostgreSQL,
+			quoted:      `"sales"."Order` + "`" + `s"`,
+			placeholder: "$2",
+			create:      `CREATE DATABASE "test-db"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.dialect), func(t *testing.T) {
+			if got := tt.dialect.QuoteIdentifier("sales.Order`s"); got != tt.quoted {
+				t.Errorf("QuoteIdentifier() = %s, want %s", got, tt.quoted)
+			}
+			if got := tt.dialect.Placeholder(2); got != tt.placeholder {
+				t.Errorf("Placeholder(2) = %s, want %s", got, tt.placeholder)
+			}
+			if got := tt.dialect.CreateDatabaseStatement("test-db"); got != tt.create {
+				t.Errorf("CreateDatabaseStatement() = %s, want %s", got, tt.create)
+			}
+		})
+	}
+
+	if got := DialectPostgreSQL.QuoteIdentifier(`a"b`); got != `"a""b"` {
+		t.Errorf("QuoteIdentifier() = %s, want %s", got, `"a""b"`)
+	}
+
+	stmt := DialectPostgreSQL.Statement("SELECT $1, $2", "a", int64(2))
+	if want := map[string]interface{}{"p1": "a", "p2": int64(2)}; !reflect.DeepEqual(stmt.Params, want) {
+		t.Errorf("Statement() params = %v, want %v", stmt.Params, want)
+	}
+}
@@ -0,0 +1,323 @@
+package spanwright
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"gopkg.in/yaml.v3"
+)
+
+// TableDump holds the rows of one table, with each row's values in Columns order
+type TableDump struct {
+	Table   string
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// FixtureWriter receives the rows of every dumped table
+type FixtureWriter interface {
+	WriteFixture(dump *TableDump) error
+}
+
+// DirFixtureWriter writes each table to <Dir>/<table>.yaml, the layout seed-injector reads.
+// A table whose fixture is already <table>.yml keeps that file.
+type DirFixtureWriter struct {
+	Dir string
+}
+
+// WriteFixture writes one table's fixture file, replacing any existing one
+func (w DirFixtureWriter) WriteFixture(dump *TableDump) error {
+	if err := os.MkdirAll(w.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create fixture directory %s: %w", w.Dir, err)
+	}
+
+	content, err := MarshalFixture(dump)
+	if err != nil {
+		return err
+	}
+
+	path := w.fixturePath(dump.Table)
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write fixture file %s: %w", path, err)
+	}
+	return nil
+}
+
+// fixturePath returns the file to write a table's fixture to. An existing <table>.yml is
+// reused, since FixtureFiles would read a new <table>.yaml next to it as a second fixture.
+func (w DirFixtureWriter) fixturePath(table string) string {
+	yml := filepath.Join(w.Dir, table+".yml")
+	if _, err := os.Stat(yml); err == nil {
+		return yml
+	}
+	return filepath.Join(w.Dir, table+".yaml")
+}
+
+// MarshalFixture encodes a table dump as fixture YAML, keeping the column order of the dump
+func MarshalFixture(dump *TableDump) ([]byte, error) {
+	rows := &yaml.Node{Kind: yaml.SequenceNode}
+	for i := range dump.Rows {
+		row, err := rowNode(dump, i)
+		if err != nil {
+			return nil, err
+		}
+		rows.Content = append(rows.Content, row)
+	}
+
+	content, err := yaml.Marshal(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode fixture for table %s: %w", dump.Table, err)
+	}
+	return content, nil
+}
+
+// Dump reads the given tables, or every table when none are given, from one consistent
+// snapshot and passes them to writer. Rows are ordered by primary key and columns follow
+// the table definition; generated columns are left out because they cannot be seeded.
+func (dm *DatabaseManager) Dump(ctx context.Context, tables []string, writer FixtureWriter) error {
+	ctx, cancel := dm.operationContext(ctx)
+	defer cancel()
+
+	schema, err := dm.DescribeSchema(ctx)
+	if err != nil {
+		return err
+	}
+
+	dumps, err := dm.readTables(ctx, schema, tables, false)
+	if err != nil {
+		return err
+	}
+
+	for _, dump := range dumps {
+		if err := writer.WriteFixture(dump); err != nil {
+			return err
+		}
+		log.Printf("📄 Dumped %d rows from %s", len(dump.Rows), dump.Table)
+	}
+	return nil
+}
+
+// readTables reads the named tables, or every table, from one read-only snapshot. Generated
+// columns are only read when withGenerated is set.
+func (dm *DatabaseManager) readTables(ctx context.Context, schema *DatabaseSchema, tables []string, withGenerated bool) ([]*TableDump, error) {
+	selected, err := selectTables(schema, tables)
+	if err != nil {
+		return nil, err
+	}
+
+	var dumps []*TableDump
+	err = dm.config.Retry.Do(ctx, "Dump", func(ctx context.Context, attempt int) error {
+		dumps = dumps[:0]
+
+		txn := dm.client.ReadOnlyTransaction()
+		defer txn.Close()
+
+		for _, table := range selected {
+			dump, err := dumpTable(ctx, txn, schema.Dialect, table, withGenerated)
+			if err != nil {
+				return err
+			}
+			dumps = append(dumps, dump)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tables of %s: %w", dm.config.DatabaseID, err)
+	}
+	return dumps, nil
+}
+
+// selectTables returns the named tables, or every table when names is empty
+func selectTables(schema *DatabaseSchema, names []string) ([]*Table, error) {
+	if len(names) == 0 {
+		return schema.Tables, nil
+	}
+
+	selected := make([]*Table, 0, len(names))
+	for _, name := range names {
+		table := schema.Table(name)
+		if table == nil {
+			return nil, fmt.Errorf("table %s does not exist", name)
+		}
+		selected = append(selected, table)
+	}
+	return selected, nil
+}
+
+// dumpStatement selects the columns of a table in primary key order, leaving out generated
+// columns unless withGenerated is set
+func dumpStatement(table *Table, dialect Dialect, withGenerated bool) (spanner.Statement, []*Column) {
+	var columns []*Column
+	var selects []string
+	for _, column := range table.Columns {
+		if column.Generated && !withGenerated {
+			continue
+		}
+		columns = append(columns, column)
+		selects = append(selects, dialect.QuoteIdentifier(column.Name))
+	}
+
+	sql := "SELECT " + strings.Join(selects, ", ") + " FROM " + dialect.QuoteIdentifier(table.QualifiedName())
+	if len(table.PrimaryKey) > 0 {
+		var keys []string
+		for _, key := range table.PrimaryKey {
+			order := dialect.QuoteIdentifier(key.Name)
+			if key.Descending {
+				order += " DESC"
+			}
+			keys = append(keys, order)
+		}
+		sql += " ORDER BY " + strings.Join(keys, ", ")
+	}
+	return spanner.NewStatement(sql), columns
+}
+
+// dumpTable reads every row of a table as fixture values
+func dumpTable(ctx context.Context, txn *spanner.ReadOnlyTransaction, dialect Dialect, table *Table, withGenerated bool) (*TableDump, error) {
+	stmt, columns := dumpStatement(table, dialect, withGenerated)
+
+	types := make([]columnType, len(columns))
+	dump := &TableDump{Table: table.QualifiedName(), Rows: [][]interface{}{}}
+	for i, column := range columns {
+		ct, err := parseColumnType(column.Type, dialect)
+		if err != nil {
+			return nil, fmt.Errorf("table %s column %s: %w", dump.Table, column.Name, err)
+		}
+		types[i] = ct
+		dump.Columns = append(dump.Columns, column.Name)
+	}
+
+	err := txn.Query(ctx, stmt).Do(func(row *spanner.Row) error {
+		values := make([]interface{}, len(columns))
+		for i, ct := range types {
+			target := columnTarget(ct)
+			if err := row.Column(i, target); err != nil {
+				return fmt.Errorf("table %s column %s: %w", dump.Table, columns[i].Name, err)
+			}
+			value, err := fixtureValue(reflect.ValueOf(target).Elem().Interface())
+			if err != nil {
+				return fmt.Errorf("table %s column %s: %w", dump.Table, columns[i].Name, err)
+			}
+			values[i] = value
+		}
+		dump.Rows = append(dump.Rows, values)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dump, nil
+}
+
+// scalarTargets are the NULL-aware types each column kind is decoded into
+var scalarTargets = map[columnKind]reflect.Type{
+	kindString:    reflect.TypeOf(spanner.NullString{}),
+	kindInt64:     reflect.TypeOf(spanner.NullInt64{}),
+	kindFloat64:   reflect.TypeOf(spanner.NullFloat64{}),
+	kindFloat32:   reflect.TypeOf(spanner.NullFloat32{}),
+	kindBool:      reflect.TypeOf(spanner.NullBool{}),
+	kindBytes:     reflect.TypeOf([]byte(nil)),
+	kindTimestamp: reflect.TypeOf(spanner.NullTime{}),
+	kindDate:      reflect.TypeOf(spanner.NullDate{}),
+	kindNumeric:   reflect.TypeOf(spanner.NullNumeric{}),
+	kindJSON:      reflect.TypeOf(spanner.NullJSON{}),
+	kindPGNumeric: reflect.TypeOf(spanner.PGNumeric{}),
+	kindPGJsonB:   reflect.TypeOf(spanner.PGJsonB{}),
+}
+
+// columnTarget returns a pointer to decode a column of the given type into
+func columnTarget(ct columnType) interface{} {
+	t := scalarTargets[ct.kind]
+	if ct.array {
+		t = reflect.SliceOf(t)
+	}
+	return reflect.New(t).Interface()
+}
+
+// fixtureValue converts a decoded column value into the form fixtures use, so that
+// convertValue turns it back into the same value
+func fixtureValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case spanner.NullString:
+		return nullable(v.Valid, v.StringVal), nil
+	case spanner.NullInt64:
+		return nullable(v.Valid, v.Int64), nil
+	case spanner.NullFloat64:
+		return nullable(v.Valid, v.Float64), nil
+	case spanner.NullFloat32:
+		// Go through the shortest float32 text so 0.1 stays 0.1 rather than 0.10000000149011612
+		f, _ := strconv.ParseFloat(strconv.FormatFloat(float64(v.Float32), 'g', -1, 32), 64)
+		return nullable(v.Valid, f), nil
+	case spanner.NullBool:
+		return nullable(v.Valid, v.Bool), nil
+	case []byte:
+		return nullable(v != nil, base64.StdEncoding.EncodeToString(v)), nil
+	case spanner.NullTime:
+		return nullable(v.Valid, v.Time.UTC().Format(time.RFC3339Nano)), nil
+	case spanner.NullDate:
+		return nullable(v.Valid, v.Date.String()), nil
+	case spanner.NullNumeric:
+		return nullable(v.Valid, numericString(&v.Numeric)), nil
+	case spanner.PGNumeric:
+		return nullable(v.Valid, v.Numeric), nil
+	case spanner.NullJSON:
+		return nullable(v.Valid, jsonFixtureValue(v.Value)), nil
+	case spanner.PGJsonB:
+		return nullable(v.Valid, jsonFixtureValue(v.Value)), nil
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("unsupported column value %T", value)
+	}
+	if rv.IsNil() {
+		return nil, nil
+	}
+	items := make([]interface{}, rv.Len())
+	for i := range items {
+		item, err := fixtureValue(rv.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+// jsonFixtureValue keeps JSON objects, arrays, numbers and booleans as YAML values. A JSON
+// string is written as its encoded text, because convertValue reads a fixture string in a
+// JSON column as encoded JSON.
+func jsonFixtureValue(value interface{}) interface{} {
+	s, ok := value.(string)
+	if !ok {
+		return value
+	}
+	encoded, _ := json.Marshal(s)
+	return string(encoded)
+}
+
+// nullable returns value, or nil when it is NULL
+func nullable(valid bool, value interface{}) interface{} {
+	if !valid {
+		return nil
+	}
+	return value
+}
+
+// numericString formats a NUMERIC without trailing zeros; Spanner keeps at most 9 decimals
+func numericString(r *big.Rat) string {
+	s := r.FloatString(9)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
@@ -0,0 +1,208 @@
+package spanwright
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+)
+
+func TestFixtureValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  interface{}
+	}{
+		{name: "string", value: spanner.NullString{StringVal: "a", Valid: true}, want: "a"},
+		{name: "null string", value: spanner.NullString{}, want: nil},
+		{name: "int64", value: spanner.NullInt64{Int64: 42, Valid: true}, want: int64(42)},
+		{name: "float32", value: spanner.NullFloat32{Float32: 0.1, Valid: true}, want: 0.1},
+		{name: "bytes", value: []byte("hi"), want: "aGk="},
+		{name: "null bytes", value: []byte(nil), want: nil},
+		{
+			name:  "timestamp in UTC",
+			value: spanner.NullTime{Time: time.Date(2024, 1, 1, 9, 0, 0, 500, time.FixedZone("JST", 9*3600)), Valid: true},
+			want:  "2024-01-01T00:00:00.0000005Z",
+		},
+		{name: "date", value: spanner.NullDate{Date: civil.Date{Year: 2024, Month: 2, Day: 29}, Valid: true}, want: "2024-02-29"},
+		{name: "numeric", value: spanner.NullNumeric{Numeric: *big.NewRat(25, 2), Valid: true}, want: "12.5"},
+		{name: "whole numeric", value: spanner.NullNumeric{Numeric: *big.NewRat(3, 1), Valid: true}, want: "3"},
+		{name: "pg numeric", value: spanner.PGNumeric{Numeric: "1.50", Valid: true}, want: "1.50"},
+		{name: "json", value: spanner.NullJSON{Value: map[string]interface{}{"a": 1.0}, Valid: true}, want: map[string]interface{}{"a": 1.0}},
+		{name: "json string", value: spanner.NullJSON{Value: "hello", Valid: true}, want: `"hello"`},
+		{name: "pg jsonb string", value: spanner.PGJsonB{Value: "hello", Valid: true}, want: `"hello"`},
+		{
+			name:  "array with null",
+			value: []spanner.NullInt64{{Int64: 1, Valid: true}, {}},
+			want:  []interface{}{int64(1), nil},
+		},
+		{name: "null array", value: []spanner.NullString(nil), want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fixtureValue(tt.value)
+			if err != nil {
+				t.Fatalf("fixtureValue() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fixtureValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalFixture(t *testing.T) {
+	dump := &TableDump{
+		Table:   "sales.Orders",
+		Columns: []string{"OrderID", "Note", "Amount", "CreatedAt"},
+		Rows: [][]interface{}{
+			{int64(1), "true", "12.5", "2024-01-01T00:00:00Z"},
+			{int64(2), nil, "3", "2024-01-02T00:00:00Z"},
+		},
+	}
+
+	content, err := MarshalFixture(dump)
+	if err != nil {
+		t.Fatalf("MarshalFixture() error = %v", err)
+	}
+
+	want := `- OrderID: 1
+  Note: "true"
+  Amount: "12.5"
+  CreatedAt: "2024-01-01T00:00:00Z"
+- OrderID: 2
+  Note: null
+  Amount: "3"
+  CreatedAt: "2024-01-02T00:00:00Z"
+`
+	if string(content) != want {
+		t.Errorf("MarshalFixture() =\n%s\nwant\n%s", content, want)
+	}
+
+	// The written file must seed back to the same values
+	dir := t.TempDir()
+	if err := (DirFixtureWriter{Dir: dir}).WriteFixture(dump); err != nil {
+		t.Fatalf("WriteFixture() error = %v", err)
+	}
+	fixture, err := LoadFixtureFile(dir + "/sales.Orders.yaml")
+	if err != nil {
+		t.Fatalf("LoadFixtureFile() error = %v", err)
+	}
+	if fixture.Table != "sales.Orders" || len(fixture.Rows) != 2 {
+		t.Fatalf("LoadFixtureFile() = %+v", fixture)
+	}
+
+	note, err := convertValue(fixture.Rows[0]["Note"], "STRING(MAX)", DialectGoogleSQL)
+	if err != nil || note != "true" {
+		t.Errorf("Note = %#v, %v, want \"true\"", note, err)
+	}
+	created, err := convertValue(fixture.Rows[1]["CreatedAt"], "TIMESTAMP", DialectGoogleSQL)
+	if err != nil || !created.(time.Time).Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("CreatedAt = %#v, %v", created, err)
+	}
+}
+
+func TestDumpStatement(t *testing.T) {
+	table := &Table{
+		Schema: "sales",
+		Name:   "Orders",
+		Columns: []*Column{
+			{Name: "CustomerID", Type: "STRING(36)"},
+			{Name: "OrderID", Type: "INT64"},
+			{Name: "Total", Type: "NUMERIC", Generated: true},
+		},
+		PrimaryKey: []KeyColumn{{Name: "CustomerID"}, {Name: "OrderID", Descending: true}},
+	}
+
+	stmt, columns := dumpStatement(table, DialectGoogleSQL, false)
+	want := "SELECT `CustomerID`, `OrderID` FROM `sales`.`Orders` ORDER BY `CustomerID`, `OrderID` DESC"
+	if stmt.SQL != want {
+		t.Errorf("dumpStatement() SQL = %s, want %s", stmt.SQL, want)
+	}
+	if len(columns) != 2 {
+		t.Errorf("dumpStatement() columns = %d, want 2 without the generated column", len(columns))
+	}
+
+	stmt, _ = dumpStatement(table, DialectPostgreSQL, true)
+	want = `SELECT "CustomerID", "OrderID", "Total" FROM "sales"."Orders" ORDER BY "CustomerID", "OrderID" DESC`
+	if stmt.SQL != want {
+		t.Errorf("dumpStatement() SQL = %s, want %s", stmt.SQL, want)
+	}
+}
+
+func TestDumpJSONRoundTrip(t *testing.T) {
+	values := []spanner.NullJSON{
+		{Value: "hello", Valid: true},
+		{Value: `{"not":"an object"}`, Valid: true},
+		{Value: map[string]interface{}{"theme": "dark"}, Valid: true},
+		{Value: []interface{}{"a", true}, Valid: true},
+	}
+
+	dump := &TableDump{Table: "Settings", Columns: []string{"ID", "Value"}}
+	for i, value := range values {
+		fixture, err := fixtureValue(value)
+		if err != nil {
+			t.Fatalf("fixtureValue(%#v) error = %v", value, err)
+		}
+		dump.Rows = append(dump.Rows, []interface{}{int64(i), fixture})
+	}
+
+	dir := t.TempDir()
+	if err := (DirFixtureWriter{Dir: dir}).WriteFixture(dump); err != nil {
+		t.Fatalf("WriteFixture() error = %v", err)
+	}
+	fixture, err := LoadFixtureFile(filepath.Join(dir, "Settings.yaml"))
+	if err != nil {
+		t.Fatalf("LoadFixtureFile() error = %v", err)
+	}
+
+	for i, want := range values {
+		got, err := convertValue(fixture.Rows[i]["Value"], "JSON", DialectGoogleSQL)
+		if err != nil {
+			t.Errorf("row %d: convertValue() error = %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("row %d: seeded %#v, want %#v", i, got, want)
+		}
+	}
+}
+
+func TestDirFixtureWriterKeepsExtension(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "Users.yml")
+	if err := os.WriteFile(existing, []byte("- UserID: old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	writer := DirFixtureWriter{Dir: dir}
+	for _, table := range []string{"Users", "Orders"} {
+		dump := &TableDump{Table: table, Columns: []string{"ID"}, Rows: [][]interface{}{{"new"}}}
+		if err := writer.WriteFixture(dump); err != nil {
+			t.Fatalf("WriteFixture(%s) error = %v", table, err)
+		}
+	}
+
+	files, err := FixtureFiles(dir)
+	if err != nil {
+		t.Fatalf("FixtureFiles() error = %v", err)
+	}
+	want := []string{existing, filepath.Join(dir, "Orders.yaml")}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("FixtureFiles() = %v, want %v", files, want)
+	}
+
+	fixture, err := LoadFixtureFile(existing)
+	if err != nil {
+		t.Fatalf("LoadFixtureFile() error = %v", err)
+	}
+	if got := fixture.Rows[0]["ID"]; got != "new" {
+		t.Errorf("Users.yml ID = %v, want the dumped row", got)
+	}
+}
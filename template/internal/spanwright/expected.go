@@ -0,0 +1,464 @@
+package spanwright
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExpectedOptions controls how GenerateExpected writes an expected-state file
+type ExpectedOptions struct {
+	// Tables limits the file to these tables; empty means every table
+	Tables []string
+	// SkipColumns leaves out volatile columns, either by name in every table (CreatedAt) or
+	// in one table (Users.CreatedAt)
+	SkipColumns []string
+	// SkipCommitTimestamps leaves out every column that allows commit timestamps
+	SkipCommitTimestamps bool
+	// PerRow writes every row under rows instead of only the first under columns
+	PerRow bool
+}
+
+// skips reports whether the column of the table is left out
+func (o ExpectedOptions) skips(table *Table, column string) bool {
+	for _, skip := range o.SkipColumns {
+		if skip == column || skip == table.QualifiedName()+"."+column {
+			return true
+		}
+	}
+	if o.SkipCommitTimestamps {
+		if c := table.Column(column); c != nil && c.AllowCommitTimestamp {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateExpected reads the current contents of the database and returns them as an
+// expected-state file in the tables: <name>: count/columns format
+func (dm *DatabaseManager) GenerateExpected(ctx context.Context, opts ExpectedOptions) ([]byte, error) {
+	ctx, cancel := dm.operationContext(ctx)
+	defer cancel()
+
+	schema, err := dm.DescribeSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dumps, err := dm.readTables(ctx, schema, opts.Tables, false)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dump := range dumps {
+		table := schema.Table(dump.Table)
+		dropColumns(dump, func(column string) bool { return opts.skips(table, column) })
+	}
+
+	header := fmt.Sprintf("Expected state of %s, generated by spanwright expected", dm.config.DatabaseID)
+	return MarshalExpected(dumps, opts.PerRow, header)
+}
+
+// dropColumns removes the columns for which skip returns true from a dump
+func dropColumns(dump *TableDump, skip func(column string) bool) {
+	var keep []int
+	var columns []string
+	for i, column := range dump.Columns {
+		if !skip(column) {
+			keep = append(keep, i)
+			columns = append(columns, column)
+		}
+	}
+
+	for r, row := range dump.Rows {
+		values := make([]interface{}, len(keep))
+		for i, index := range keep {
+			values[i] = row[index]
+		}
+		dump.Rows[r] = values
+	}
+	dump.Columns = columns
+}
+
+// MarshalExpected encodes table dumps as an expected-state file. Each table gets its row
+// count and either the first row under columns or, with perRow, every row under rows.
+func MarshalExpected(dumps []*TableDump, perRow bool, header string) ([]byte, error) {
+	tables := &yaml.Node{Kind: yaml.MappingNode}
+	for _, dump := range dumps {
+		entry := &yaml.Node{Kind: yaml.MappingNode}
+		entry.Content = append(entry.Content, scalarNode("count"), &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: fmt.Sprint(len(dump.Rows))})
+
+		if perRow && len(dump.Rows) > 0 {
+			rows := &yaml.Node{Kind: yaml.SequenceNode}
+			for i := range dump.Rows {
+				row, err := rowNode(dump, i)
+				if err != nil {
+					return nil, err
+				}
+				rows.Content = append(rows.Content, row)
+			}
+			entry.Content = append(entry.Content, scalarNode("rows"), rows)
+		} else if len(dump.Rows) > 0 {
+			row, err := rowNode(dump, 0)
+			if err != nil {
+				return nil, err
+			}
+			entry.Content = append(entry.Content, scalarNode("columns"), row)
+		}
+
+		tables.Content = append(tables.Content, scalarNode(dump.Table), entry)
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{scalarNode("tables"), tables}}
+	if header != "" {
+		root.HeadComment = header
+	}
+
+	var out strings.Builder
+	encoder := yaml.NewEncoder(&out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return nil, fmt.Errorf("failed to encode expected state: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode expected state: %w", err)
+	}
+	return []byte(out.String()), nil
+}
+
+// rowNode encodes row i of a dump as a mapping in column order
+func rowNode(dump *TableDump, i int) (*yaml.Node, error) {
+	row := &yaml.Node{Kind: yaml.MappingNode}
+	for j, column := range dump.Columns {
+		value := &yaml.Node{}
+		if err := value.Encode(dump.Rows[i][j]); err != nil {
+			return nil, fmt.Errorf("table %s row %d column %s: %w", dump.Table, i+1, column, err)
+		}
+		row.Content = append(row.Content, scalarNode(column), value)
+	}
+	return row, nil
+}
+
+// scalarNode returns a plain YAML string node, used for mapping keys
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+}
+
+// ExpectedState is a parsed expected-state file
+type ExpectedState struct {
+	Tables  []*TableExpectation
+	Queries []*QueryExpectation
+	// Changes is checked against the difference from a baseline snapshot
+	Changes *ChangeExpectation
+}
+
+// Databases returns the logical names of the other databases the queries read
+func (s *ExpectedState) Databases() []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, query := range s.Queries {
+		for _, source := range []*QuerySource{&query.QuerySource, query.SubsetOf} {
+			if source != nil && source.Database != "" && !seen[source.Database] {
+				seen[source.Database] = true
+				names = append(names, source.Database)
+			}
+		}
+	}
+	return names
+}
+
+// TableExpectation describes the expected contents of one table. Count is nil when the
+// file does not check it; Columns must match some row, Rows must match the rows as Order
+// says, ByKey rows are looked up by primary key, and no row may match an Absent entry.
+// Exact also fails on rows that no expectation accounts for.
+type TableExpectation struct {
+	Table   string
+	Line    int
+	Count   *int
+	Columns ExpectedRow
+	Rows    []ExpectedRow
+	Order   RowOrder
+	ByKey   []KeyedRow
+	Absent  []ExpectedRow
+	Exact   bool
+}
+
+// RowOrder is how the rows of a TableExpectation are matched
+type RowOrder string
+
+const (
+	// RowsOrdered compares expected rows with the table's rows in primary key order
+	RowsOrdered RowOrder = "ordered"
+	// RowsSet matches each expected row with a different row, in any order
+	RowsSet RowOrder = "set"
+)
+
+// KeyedRow is an expected row addressed by its primary key values
+type KeyedRow struct {
+	Key  []interface{}
+	Line int
+	Row  ExpectedRow
+}
+
+// ExpectedRow holds expected column values in file order
+type ExpectedRow []ExpectedValue
+
+// ExpectedValue is the expected value of one column: a plain value, or a matcher such as
+// !regex, !any, !notnull, !uuid, !range, !within, !after or !json
+type ExpectedValue struct {
+	Column string
+	Value  interface{}
+}
+
+// LoadExpectedFile reads and parses an expected-state file
+func LoadExpectedFile(path string) (*ExpectedState, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read expected file %s: %w", path, err)
+	}
+
+	state, err := ParseExpected(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expected file %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// ParseExpected parses an expected-state file in the tables: <name>: count/columns/rows format
+func ParseExpected(content []byte) (*ExpectedState, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+
+	state := &ExpectedState{}
+	if len(doc.Content) == 0 {
+		return state, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: expected a mapping with a tables key", root.Line)
+	}
+	for i := 0; i < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if key.Value == "changes" {
+			changes, err := parseChangeExpectation(value)
+			if err != nil {
+				return nil, err
+			}
+			state.Changes = changes
+			continue
+		}
+		if key.Value != "tables" && key.Value != "queries" {
+			return nil, fmt.Errorf("line %d: unknown key %q", key.Line, key.Value)
+		}
+		if value.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("line %d: %s must be a mapping of names", value.Line, key.Value)
+		}
+		for j := 0; j < len(value.Content); j += 2 {
+			if key.Value == "queries" {
+				query, err := parseQueryExpectation(value.Content[j], value.Content[j+1])
+				if err != nil {
+					return nil, err
+				}
+				state.Queries = append(state.Queries, query)
+				continue
+			}
+
+			table, err := parseTableExpectation(value.Content[j], value.Content[j+1])
+			if err != nil {
+				return nil, err
+			}
+			state.Tables = append(state.Tables, table)
+		}
+	}
+	return state, nil
+}
+
+// parseTableExpectation parses the entry of one table under tables
+func parseTableExpectation(key, value *yaml.Node) (*TableExpectation, error) {
+	table := &TableExpectation{Table: key.Value, Line: key.Line, Order: RowsOrdered}
+	if value.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: table %s must be a mapping", value.Line, table.Table)
+	}
+
+	for i := 0; i < len(value.Content); i += 2 {
+		field, node := value.Content[i], value.Content[i+1]
+		switch field.Value {
+		case "count":
+			var count int
+			if err := node.Decode(&count); err != nil || count < 0 {
+				return nil, fmt.Errorf("line %d: count of table %s must be a non-negative integer", node.Line, table.Table)
+			}
+			table.Count = &count
+		case "columns":
+			row, err := parseExpectedRow(node)
+			if err != nil {
+				return nil, fmt.Errorf("table %s: %w", table.Table, err)
+			}
+			table.Columns = row
+		case "rows":
+			rows, err := parseExpectedRows(node, false)
+			if err != nil {
+				return nil, fmt.Errorf("table %s: %w", table.Table, err)
+			}
+			table.Rows = rows
+		case "order":
+			order, err := parseRowOrder(node)
+			if err != nil {
+				return nil, fmt.Errorf("table %s: %w", table.Table, err)
+			}
+			table.Order = order
+		case "by_key":
+			rows, err := parseKeyedRows(node)
+			if err != nil {
+				return nil, fmt.Errorf("table %s: %w", table.Table, err)
+			}
+			table.ByKey = rows
+		case "absent":
+			rows, err := parseExpectedRows(node, true)
+			if err != nil {
+				return nil, fmt.Errorf("table %s: %w", table.Table, err)
+			}
+			table.Absent = rows
+		case "exact":
+			if err := node.Decode(&table.Exact); err != nil {
+				return nil, fmt.Errorf("line %d: exact of table %s must be true or false", node.Line, table.Table)
+			}
+		default:
+			return nil, fmt.Errorf("line %d: unknown key %q in table %s", field.Line, field.Value, table.Table)
+		}
+	}
+
+	if table.Columns != nil && table.Rows != nil {
+		return nil, fmt.Errorf("line %d: table %s cannot have both columns and rows", key.Line, table.Table)
+	}
+	return table, nil
+}
+
+// parseRowOrder parses the order of expected rows
+func parseRowOrder(node *yaml.Node) (RowOrder, error) {
+	switch order := RowOrder(node.Value); order {
+	case RowsOrdered, RowsSet:
+		return order, nil
+	}
+	return "", fmt.Errorf("line %d: order must be ordered or set", node.Line)
+}
+
+// parseExpectedRows parses a list of expected rows; absent entries need at least one column
+func parseExpectedRows(node *yaml.Node, absent bool) ([]ExpectedRow, error) {
+	if node.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("line %d: expected a list of rows", node.Line)
+	}
+
+	rows := []ExpectedRow{}
+	for _, item := range node.Content {
+		row, err := parseExpectedRow(item)
+		if err != nil {
+			return nil, err
+		}
+		if absent && len(row) == 0 {
+			return nil, fmt.Errorf("line %d: absent entries need at least one column", item.Line)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseKeys parses a list of primary keys, each a value or a list of values
+func parseKeys(node *yaml.Node) ([][]interface{}, error) {
+	if node.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("line %d: expected a list of primary keys", node.Line)
+	}
+
+	var keys [][]interface{}
+	for _, item := range node.Content {
+		key, err := parseKey(item)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// parseKey parses primary key values: a scalar, or a flow list such as [customer-1, 2]
+// for composite keys
+func parseKey(node *yaml.Node) ([]interface{}, error) {
+	parts := []*yaml.Node{node}
+	if node.Kind == yaml.SequenceNode {
+		parts = node.Content
+	}
+
+	var key []interface{}
+	for _, part := range parts {
+		var value interface{}
+		if part.Kind != yaml.ScalarNode || part.Decode(&value) != nil {
+			return nil, fmt.Errorf("line %d: keys must be values or lists of values", part.Line)
+		}
+		key = append(key, value)
+	}
+	return key, nil
+}
+
+// parseKeyedRows parses a mapping from primary keys to expected rows
+func parseKeyedRows(node *yaml.Node) ([]KeyedRow, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: expected a mapping of primary keys to rows", node.Line)
+	}
+
+	var rows []KeyedRow
+	for i := 0; i < len(node.Content); i += 2 {
+		key, err := parseKey(node.Content[i])
+		if err != nil {
+			return nil, err
+		}
+		keyed := KeyedRow{Key: key, Line: node.Content[i].Line}
+
+		row, err := parseExpectedRow(node.Content[i+1])
+		if err != nil {
+			return nil, err
+		}
+		keyed.Row = row
+		rows = append(rows, keyed)
+	}
+	return rows, nil
+}
+
+// parseExpectedRow parses a mapping of column names to expected values
+func parseExpectedRow(node *yaml.Node) (ExpectedRow, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: expected a mapping of column values", node.Line)
+	}
+
+	row := ExpectedRow{}
+	for i := 0; i < len(node.Content); i += 2 {
+		value, err := parseExpectedValue(node.Content[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", node.Content[i].Value, err)
+		}
+		row = append(row, ExpectedValue{Column: node.Content[i].Value, Value: value})
+	}
+	return row, nil
+}
+
+// parseExpectedValue parses a plain expected value or a matcher
+func parseExpectedValue(node *yaml.Node) (interface{}, error) {
+	m, err := parseMatcher(node)
+	if err != nil {
+		return nil, err
+	}
+	if m != nil {
+		return m, nil
+	}
+
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,118 @@
+package spanwright
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarshalExpected(t *testing.T) {
+	newDumps := func() []*TableDump {
+		return []*TableDump{
+			{
+				Table:   "Users",
+				Columns: []string{"UserID", "Name", "CreatedAt"},
+				Rows: [][]interface{}{
+					{"user-001", "Alice", "2024-01-01T00:00:00Z"},
+					{"user-002", "Bob", "2024-01-02T00:00:00Z"},
+				},
+			},
+			{Table: "Empty", Columns: []string{"ID"}, Rows: [][]interface{}{}},
+		}
+	}
+
+	t.Run("first row", func(t *testing.T) {
+		dumps := newDumps()
+		users := &Table{Name: "Users", Columns: []*Column{{Name: "UserID"}, {Name: "Name"}, {Name: "CreatedAt", AllowCommitTimestamp: true}}}
+		opts := ExpectedOptions{SkipCommitTimestamps: true}
+		dropColumns(dumps[0], func(column string) bool { return opts.skips(users, column) })
+
+		got, err := MarshalExpected(dumps, false, "Expected state of primary-db")
+		if err != nil {
+			t.Fatalf("MarshalExpected() error = %v", err)
+		}
+
+		want := `# Expected state of primary-db
+tables:
+  Users:
+    count: 2
+    columns:
+      UserID: user-001
+      Name: Alice
+  Empty:
+    count: 0
+`
+		if string(got) != want {
+			t.Errorf("MarshalExpected() =\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("per row", func(t *testing.T) {
+		dumps := newDumps()
+		users := &Table{Name: "Users"}
+		opts := ExpectedOptions{SkipColumns: []string{"Users.Name", "CreatedAt"}}
+		dropColumns(dumps[0], func(column string) bool { return opts.skips(users, column) })
+
+		got, err := MarshalExpected(dumps[:1], true, "")
+		if err != nil {
+			t.Fatalf("MarshalExpected() error = %v", err)
+		}
+
+		want := `tables:
+  Users:
+    count: 2
+    rows:
+      - UserID: user-001
+      - UserID: user-002
+`
+		if string(got) != want {
+			t.Errorf("MarshalExpected() =\n%s\nwant\n%s", got, want)
+		}
+	})
+}
+
+func TestParseExpected(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "example format", content: "tables:\n  Users:\n    count: 1\n    columns:\n      UserID: \"user-001\"\n      # CreatedAt: \"2024-01-01T00:00:00Z\"\n"},
+		{name: "rows", content: "tables:\n  Users:\n    rows:\n      - UserID: a\n"},
+		{name: "empty file", content: ""},
+		{name: "unknown table key", content: "tables:\n  Users:\n    cols: {}\n", wantErr: `line 3: unknown key "cols" in table Users`},
+		{name: "negative count", content: "tables:\n  Users:\n    count: -1\n", wantErr: "non-negative"},
+		{name: "columns and rows", content: "tables:\n  Users:\n    columns: {A: 1}\n    rows: []\n", wantErr: "both columns and rows"},
+		{name: "not a mapping", content: "- Users\n", wantErr: "tables key"},
+		{name: "keyed rows", content: "tables:\n  Orders:\n    order: set\n    exact: true\n    by_key:\n      [c-1, 2]: {Total: 100}\n    absent:\n      - {Status: cancelled}\n"},
+		{name: "unknown order", content: "tables:\n  Users:\n    order: random\n", wantErr: "ordered or set"},
+		{name: "by_key list", content: "tables:\n  Users:\n    by_key: []\n", wantErr: "mapping of primary keys"},
+		{name: "empty absent entry", content: "tables:\n  Users:\n    absent: [{}]\n", wantErr: "at least one column"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, err := ParseExpected([]byte(tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseExpected() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseExpected() error = %v", err)
+			}
+			if tt.content != "" && len(state.Tables) != 1 {
+				t.Errorf("ParseExpected() tables = %d, want 1", len(state.Tables))
+			}
+		})
+	}
+
+	state, err := ParseExpected([]byte("tables:\n  Users:\n    count: 1\n    columns:\n      UserID: user-001\n      Status: 1\n"))
+	if err != nil {
+		t.Fatalf("ParseExpected() error = %v", err)
+	}
+	users := state.Tables[0]
+	if users.Table != "Users" || *users.Count != 1 || len(users.Columns) != 2 || users.Columns[1].Column != "Status" || users.Columns[1].Value != 1 {
+		t.Errorf("ParseExpected() = %+v", users)
+	}
+}
@@ -0,0 +1,137 @@
+package spanwright
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrNotEmulator is wrapped by every EmulatorGuardError
+var ErrNotEmulator = errors.New("refusing to connect to a non-emulator Spanner endpoint")
+
+// EmulatorGuardError reports why the emulator guard refused a connection
+type EmulatorGuardError struct {
+	Host   string
+	Reason string
+}
+
+func (e *EmulatorGuardError) Error() string {
+	if e.Host == "" {
+		return fmt.Sprintf("%v: %s", ErrNotEmulator, e.Reason)
+	}
+	return fmt.Sprintf("%v: %s (SPANNER_EMULATOR_HOST=%s)", ErrNotEmulator, e.Reason, e.Host)
+}
+
+// Unwrap allows errors.Is(err, ErrNotEmulator)
+func (e *EmulatorGuardError) Unwrap() error {
+	return ErrNotEmulator
+}
+
+// EmulatorGuard decides whether Spanner clients may be created for an emulator host
+type EmulatorGuard struct {
+	// AllowedHosts are host names or IPs accepted in addition to loopback addresses
+	AllowedHosts []string
+	// AllowNonEmulator disables the guard, but only when it names the current Environment
+	AllowNonEmulator string
+	// Environment is the configured ENVIRONMENT value
+	Environment string
+	// LookupIP resolves host names; defaults to net.LookupIP
+	LookupIP func(host string) ([]net.IP, error)
+}
+
+// CheckEnvironment checks the SPANNER_EMULATOR_HOST the Spanner client libraries will use
+func (g *EmulatorGuard) CheckEnvironment() error {
+	return g.Check(os.Getenv("SPANNER_EMULATOR_HOST"))
+}
+
+// Check returns an *EmulatorGuardError unless emulatorHost is a loopback or allow-listed
+// address, or the guard has been explicitly overridden for the current environment
+func (g *EmulatorGuard) Check(emulatorHost string) error {
+	err := g.check(emulatorHost)
+	if err == nil {
+		return nil
+	}
+
+	if g.overridden() {
+		log.Printf("⚠️ Emulator guard overridden for environment %q: %v", g.Environment, err)
+		return nil
+	}
+	return err
+}
+
+func (g *EmulatorGuard) check(emulatorHost string) error {
+	if emulatorHost == "" {
+		return &EmulatorGuardError{Reason: "SPANNER_EMULATOR_HOST is not set - this framework only works with the emulator for safety"}
+	}
+
+	host, err := splitEmulatorHost(emulatorHost)
+	if err != nil {
+		return &EmulatorGuardError{Host: emulatorHost, Reason: err.Error()}
+	}
+
+	for _, allowed := range g.AllowedHosts {
+		if strings.EqualFold(strings.TrimSpace(allowed), host) {
+			return nil
+		}
+	}
+
+	if isLoopbackHost(host) {
+		return nil
+	}
+
+	lookup := g.LookupIP
+	if lookup == nil {
+		lookup = net.LookupIP
+	}
+	ips, err := lookup(host)
+	if err != nil {
+		return &EmulatorGuardError{Host: emulatorHost, Reason: fmt.Sprintf("cannot resolve %s: %v", host, err)}
+	}
+	if len(ips) == 0 {
+		return &EmulatorGuardError{Host: emulatorHost, Reason: fmt.Sprintf("%s resolves to no addresses", host)}
+	}
+	for _, ip := range ips {
+		if !ip.IsLoopback() {
+			return &EmulatorGuardError{
+				Host:   emulatorHost,
+				Reason: fmt.Sprintf("%s resolves to non-loopback address %s; add it to SPANNER_EMULATOR_ALLOWED_HOSTS if it is an emulator", host, ip),
+			}
+		}
+	}
+
+	return nil
+}
+
+// overridden reports whether ALLOW_NON_EMULATOR names the current, permitted environment
+func (g *EmulatorGuard) overridden() bool {
+	if g.AllowNonEmulator == "" || g.Environment == "" {
+		return false
+	}
+	if validateEnvironment(g.Environment) != nil {
+		return false
+	}
+	return strings.EqualFold(g.AllowNonEmulator, g.Environment)
+}
+
+// splitEmulatorHost validates a host:port pair and returns the host part
+func splitEmulatorHost(emulatorHost string) (string, error) {
+	host, port, err := net.SplitHostPort(emulatorHost)
+	if err != nil {
+		return "", fmt.Errorf("emulator host must be in host:port format: %w", err)
+	}
+
+	portNumber, err := strconv.Atoi(port)
+	if err != nil || portNumber < 1 || portNumber > 65535 {
+		return "", fmt.Errorf("emulator host has an invalid port %q", port)
+	}
+
+	if host == "" || strings.ContainsAny(host, "/\\;&|` $") {
+		return "", fmt.Errorf("emulator host has an invalid host name %q", host)
+	}
+
+	return host, nil
+}
@@ -0,0 +1,113 @@
+package spanwright
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestEmulatorGuardCheck(t *testing.T) {
+	resolver := func(host string) ([]net.IP, error) {
+		switch host {
+		case "spanner-emulator":
+			return []net.IP{net.ParseIP("172.18.0.2")}, nil
+		case "emulator.local":
+			return []net.IP{net.ParseIP("127.0.0.1")}, nil
+		case "spanner.googleapis.com":
+			return []net.IP{net.ParseIP("142.250.0.1")}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+
+	tests := []struct {
+		name         string
+		guard        EmulatorGuard
+		emulatorHost string
+		wantErr      bool
+	}{
+		{
+			name:         "localhost",
+			emulatorHost: "localhost:9010",
+		},
+		{
+			name:         "IPv6 loopback",
+			emulatorHost: "[::1]:9010",
+		},
+		{
+			name:         "name resolving to loopback",
+			emulatorHost: "emulator.local:9010",
+		},
+		{
+			name:         "unset host",
+			emulatorHost: "",
+			wantErr:      true,
+		},
+		{
+			name:         "missing port",
+			emulatorHost: "localhost",
+			wantErr:      true,
+		},
+		{
+			name:         "command injection attempt",
+			emulatorHost: "localhost:9010;rm -rf /",
+			wantErr:      true,
+		},
+		{
+			name:         "non-loopback host",
+			emulatorHost: "spanner.googleapis.com:443",
+			wantErr:      true,
+		},
+		{
+			name:         "unresolvable host",
+			emulatorHost: "unknown:9010",
+			wantErr:      true,
+		},
+		{
+			name:         "allow-listed docker host",
+			guard:        EmulatorGuard{AllowedHosts: []string{"spanner-emulator"}},
+			emulatorHost: "spanner-emulator:9010",
+		},
+		{
+			name:         "docker host without allow-list",
+			emulatorHost: "spanner-emulator:9010",
+			wantErr:      true,
+		},
+		{
+			name:         "override matching environment",
+			guard:        EmulatorGuard{AllowNonEmulator: "staging", Environment: "staging"},
+			emulatorHost: "spanner.googleapis.com:443",
+		},
+		{
+			name:         "override for a different environment",
+			guard:        EmulatorGuard{AllowNonEmulator: "staging", Environment: "development"},
+			emulatorHost: "spanner.googleapis.com:443",
+			wantErr:      true,
+		},
+		{
+			name:         "override for a disallowed environment",
+			guard:        EmulatorGuard{AllowNonEmulator: "production", Environment: "production"},
+			emulatorHost: "spanner.googleapis.com:443",
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := tt.guard
+			guard.LookupIP = resolver
+
+			err := guard.Check(tt.emulatorHost)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EmulatorGuard.Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+
+			var guardErr *EmulatorGuardError
+			if !errors.As(err, &guardErr) || !errors.Is(err, ErrNotEmulator) {
+				t.Errorf("EmulatorGuard.Check() error = %v, want *EmulatorGuardError wrapping ErrNotEmulator", err)
+			}
+		})
+	}
+}
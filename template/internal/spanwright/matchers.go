@@ -0,0 +1,335 @@
+package spanwright
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// matcher is an expected value that accepts more than one column value, written in
+// expected-state files as a YAML tag such as !regex or !within
+type matcher interface {
+	// match reports whether a column value, as read by dumpTable, is accepted
+	match(actual interface{}, row matchRow) (bool, error)
+	// String returns the matcher as written in the file
+	String() string
+}
+
+// matchRow is what a matcher can see besides the column value
+type matchRow struct {
+	// column returns the value of another column of the same row
+	column func(name string) (interface{}, bool)
+	// now is when validation started
+	now time.Time
+}
+
+// uuidPattern matches the textual form of any UUID version
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// parseMatcher returns the matcher written by a tagged node, or nil when the node is a
+// plain value
+func parseMatcher(node *yaml.Node) (matcher, error) {
+	if !strings.HasPrefix(node.Tag, "!") || strings.HasPrefix(node.Tag, "!!") {
+		return nil, nil
+	}
+
+	switch node.Tag {
+	case "!any":
+		return anyMatcher{}, nil
+	case "!notnull":
+		return notNullMatcher{}, nil
+	case "!uuid":
+		return regexMatcher{name: "!uuid", pattern: uuidPattern}, nil
+	case "!regex":
+		if node.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("line %d: !regex takes a pattern", node.Line)
+		}
+		pattern, err := regexp.Compile(node.Value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid !regex pattern: %w", node.Line, err)
+		}
+		return regexMatcher{name: "!regex " + node.Value, pattern: pattern}, nil
+	case "!range":
+		return parseRangeMatcher(node)
+	case "!within":
+		d, err := time.ParseDuration(node.Value)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("line %d: !within takes a positive duration such as 5m", node.Line)
+		}
+		return withinMatcher{within: d}, nil
+	case "!after":
+		if node.Kind != yaml.ScalarNode || node.Value == "" {
+			return nil, fmt.Errorf("line %d: !after takes a column name or an RFC 3339 timestamp", node.Line)
+		}
+		return afterMatcher{reference: node.Value}, nil
+	case "!json":
+		return parseJSONMatcher(node)
+	}
+	return nil, fmt.Errorf("line %d: unknown matcher %s", node.Line, node.Tag)
+}
+
+// anyMatcher accepts every value, including NULL
+type anyMatcher struct{}
+
+func (anyMatcher) match(interface{}, matchRow) (bool, error) { return true, nil }
+
+func (anyMatcher) String() string { return "!any" }
+
+// notNullMatcher accepts every value except NULL
+type notNullMatcher struct{}
+
+func (notNullMatcher) match(actual interface{}, _ matchRow) (bool, error) {
+	return actual != nil, nil
+}
+
+func (notNullMatcher) String() string { return "!notnull" }
+
+// regexMatcher accepts values whose text matches a pattern
+type regexMatcher struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+func (m regexMatcher) match(actual interface{}, _ matchRow) (bool, error) {
+	if actual == nil {
+		return false, nil
+	}
+	s, ok := actual.(string)
+	if !ok {
+		s = fmt.Sprint(actual)
+	}
+	return m.pattern.MatchString(s), nil
+}
+
+func (m regexMatcher) String() string { return m.name }
+
+// rangeMatcher accepts numbers between min and max, inclusive; either bound may be absent
+type rangeMatcher struct {
+	min, max *big.Rat
+	text     string
+}
+
+// parseRangeMatcher parses !range [min, max] or !range {min: 1, max: 10}
+func parseRangeMatcher(node *yaml.Node) (matcher, error) {
+	var bounds []*yaml.Node
+	switch node.Kind {
+	case yaml.SequenceNode:
+		if len(node.Content) != 2 {
+			return nil, fmt.Errorf("line %d: !range takes [min, max]", node.Line)
+		}
+		bounds = node.Content
+	case yaml.MappingNode:
+		bounds = make([]*yaml.Node, 2)
+		for i := 0; i < len(node.Content); i += 2 {
+			switch node.Content[i].Value {
+			case "min":
+				bounds[0] = node.Content[i+1]
+			case "max":
+				bounds[1] = node.Content[i+1]
+			default:
+				return nil, fmt.Errorf("line %d: unknown !range key %q", node.Content[i].Line, node.Content[i].Value)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("line %d: !range takes [min, max] or {min, max}", node.Line)
+	}
+
+	var m rangeMatcher
+	text := []string{"null", "null"}
+	for i, bound := range bounds {
+		if bound == nil || bound.Tag == "!!null" {
+			continue
+		}
+		text[i] = bound.Value
+		r, ok := new(big.Rat).SetString(bound.Value)
+		if !ok {
+			return nil, fmt.Errorf("line %d: !range bound %q is not a number", bound.Line, bound.Value)
+		}
+		if i == 0 {
+			m.min = r
+		} else {
+			m.max = r
+		}
+	}
+	if m.min == nil && m.max == nil {
+		return nil, fmt.Errorf("line %d: !range needs a min or a max", node.Line)
+	}
+	if m.min != nil && m.max != nil && m.min.Cmp(m.max) > 0 {
+		return nil, fmt.Errorf("line %d: !range min is greater than max", node.Line)
+	}
+	m.text = "!range [" + text[0] + ", " + text[1] + "]"
+	return m, nil
+}
+
+func (m rangeMatcher) match(actual interface{}, _ matchRow) (bool, error) {
+	if actual == nil {
+		return false, nil
+	}
+	if f, ok := actual.(float64); ok {
+		actual = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	r, err := toRat(actual)
+	if err != nil {
+		return false, fmt.Errorf("!range needs a numeric column: %w", err)
+	}
+	return (m.min == nil || r.Cmp(m.min) >= 0) && (m.max == nil || r.Cmp(m.max) <= 0), nil
+}
+
+func (m rangeMatcher) String() string { return m.text }
+
+// withinMatcher accepts timestamps at most a duration away from when validation started
+type withinMatcher struct {
+	within time.Duration
+}
+
+func (m withinMatcher) match(actual interface{}, row matchRow) (bool, error) {
+	if actual == nil {
+		return false, nil
+	}
+	t, err := toTimestamp(actual)
+	if err != nil {
+		return false, fmt.Errorf("!within needs a TIMESTAMP column: %w", err)
+	}
+	d := row.now.Sub(t)
+	return d >= -m.within && d <= m.within, nil
+}
+
+func (m withinMatcher) String() string { return "!within " + m.within.String() }
+
+// afterMatcher accepts timestamps not earlier than another column of the row or a fixed time
+type afterMatcher struct {
+	reference string
+}
+
+func (m afterMatcher) match(actual interface{}, row matchRow) (bool, error) {
+	if actual == nil {
+		return false, nil
+	}
+	t, err := toTimestamp(actual)
+	if err != nil {
+		return false, fmt.Errorf("!after needs a TIMESTAMP column: %w", err)
+	}
+
+	reference, err := time.Parse(time.RFC3339Nano, m.reference)
+	if err != nil {
+		value, ok := row.column(m.reference)
+		if !ok {
+			return false, fmt.Errorf("!after references unknown column %s", m.reference)
+		}
+		if value == nil {
+			return false, nil
+		}
+		if reference, err = toTimestamp(value); err != nil {
+			return false, fmt.Errorf("!after needs %s to be a TIMESTAMP: %w", m.reference, err)
+		}
+	}
+	return !t.Before(reference), nil
+}
+
+func (m afterMatcher) String() string { return "!after " + m.reference }
+
+// jsonMatcher accepts JSON values that contain the expected value: objects need the
+// expected keys, arrays need an element containing each expected element, and scalars
+// must be equal
+type jsonMatcher struct {
+	expected interface{}
+}
+
+// parseJSONMatcher parses !json with a YAML value or a string of JSON
+func parseJSONMatcher(node *yaml.Node) (matcher, error) {
+	var value interface{}
+	if node.Kind == yaml.ScalarNode {
+		if err := json.Unmarshal([]byte(node.Value), &value); err != nil {
+			return nil, fmt.Errorf("line %d: invalid !json value: %w", node.Line, err)
+		}
+		return jsonMatcher{expected: value}, nil
+	}
+
+	if err := node.Decode(&value); err != nil {
+		return nil, fmt.Errorf("line %d: invalid !json value: %w", node.Line, err)
+	}
+	normalized, err := normalizeJSON(value)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: invalid !json value: %w", node.Line, err)
+	}
+	return jsonMatcher{expected: normalized}, nil
+}
+
+func (m jsonMatcher) match(actual interface{}, _ matchRow) (bool, error) {
+	if actual == nil {
+		return false, nil
+	}
+	if s, ok := actual.(string); ok {
+		if err := json.Unmarshal([]byte(s), &actual); err != nil {
+			return false, fmt.Errorf("!json needs a JSON column: %w", err)
+		}
+	}
+	normalized, err := normalizeJSON(actual)
+	if err != nil {
+		return false, err
+	}
+	return jsonContains(normalized, m.expected), nil
+}
+
+func (m jsonMatcher) String() string {
+	b, _ := json.Marshal(m.expected)
+	return "!json " + string(b)
+}
+
+// normalizeJSON round-trips a value through encoding/json so numbers are float64 and
+// objects are map[string]interface{}
+func normalizeJSON(value interface{}) (interface{}, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var normalized interface{}
+	if err := json.Unmarshal(b, &normalized); err != nil {
+		return nil, err
+	}
+	return normalized, nil
+}
+
+// jsonContains reports whether actual contains expected, like PostgreSQL's @> operator
+func jsonContains(actual, expected interface{}) bool {
+	switch want := expected.(type) {
+	case map[string]interface{}:
+		got, ok := actual.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for key, value := range want {
+			item, ok := got[key]
+			if !ok || !jsonContains(item, value) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		got, ok := actual.([]interface{})
+		if !ok {
+			return false
+		}
+		for _, value := range want {
+			found := false
+			for _, item := range got {
+				if jsonContains(item, value) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(actual, expected)
+}
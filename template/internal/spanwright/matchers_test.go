@@ -0,0 +1,100 @@
+package spanwright
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatchers(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	row := map[string]interface{}{"CreatedAt": "2024-06-01T11:00:00Z", "DeletedAt": nil}
+
+	tests := []struct {
+		name    string
+		value   string
+		actual  interface{}
+		want    bool
+		wantErr bool
+	}{
+		{name: "any", value: "!any", actual: nil, want: true},
+		{name: "notnull", value: "!notnull", actual: "x", want: true},
+		{name: "notnull on NULL", value: "!notnull", actual: nil, want: false},
+		{name: "regex", value: `!regex '^user-\d{3}$'`, actual: "user-001", want: true},
+		{name: "regex on int", value: `!regex '^4\d$'`, actual: int64(42), want: true},
+		{name: "regex mismatch", value: `!regex '^user-'`, actual: "admin-001", want: false},
+		{name: "uuid", value: "!uuid", actual: "9b2f5c1e-3c4d-4e5f-8a6b-7c8d9e0f1a2b", want: true},
+		{name: "not a uuid", value: "!uuid", actual: "user-001", want: false},
+		{name: "range", value: "!range [1, 10]", actual: int64(10), want: true},
+		{name: "range below", value: "!range [1, 10]", actual: int64(0), want: false},
+		{name: "range min only", value: "!range {min: 0.5}", actual: "1000.25", want: true},
+		{name: "range max only float", value: "!range {max: 0.3}", actual: 0.1, want: true},
+		{name: "range on string", value: "!range [1, 2]", actual: "abc", wantErr: true},
+		{name: "within", value: "!within 5m", actual: "2024-06-01T12:04:00Z", want: true},
+		{name: "not within", value: "!within 5m", actual: "2024-06-01T11:50:00Z", want: false},
+		{name: "after column", value: "!after CreatedAt", actual: "2024-06-01T11:00:00Z", want: true},
+		{name: "before column", value: "!after CreatedAt", actual: "2024-06-01T10:59:59Z", want: false},
+		{name: "after NULL column", value: "!after DeletedAt", actual: "2024-06-01T11:00:00Z", want: false},
+		{name: "after timestamp", value: "!after 2024-01-01T00:00:00Z", actual: "2024-06-01T11:00:00Z", want: true},
+		{name: "after unknown column", value: "!after Missing", actual: "2024-06-01T11:00:00Z", wantErr: true},
+		{
+			name:   "json subset",
+			value:  "!json {plan: pro, tags: [b], limits: {seats: 5}}",
+			actual: map[string]interface{}{"plan": "pro", "tags": []interface{}{"a", "b"}, "limits": map[string]interface{}{"seats": 5.0, "storage": 10.0}},
+			want:   true,
+		},
+		{name: "json string", value: `!json '{"plan": "pro"}'`, actual: `{"plan": "pro", "seats": 5}`, want: true},
+		{name: "json missing key", value: "!json {plan: free}", actual: map[string]interface{}{"plan": "pro"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, err := ParseExpected([]byte("tables:\n  T:\n    columns:\n      C: " + tt.value + "\n"))
+			if err != nil {
+				t.Fatalf("ParseExpected() error = %v", err)
+			}
+			m, ok := state.Tables[0].Columns[0].Value.(matcher)
+			if !ok {
+				t.Fatalf("ParseExpected() value = %#v, want a matcher", state.Tables[0].Columns[0].Value)
+			}
+
+			got, err := m.match(tt.actual, matchRow{
+				column: func(name string) (interface{}, bool) {
+					value, ok := row[name]
+					return value, ok
+				},
+				now: now,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("match() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMatcherErrors(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr string
+	}{
+		{value: "!regex '['", wantErr: "invalid !regex pattern"},
+		{value: "!range [1]", wantErr: "[min, max]"},
+		{value: "!range [10, 1]", wantErr: "min is greater than max"},
+		{value: "!range {}", wantErr: "needs a min or a max"},
+		{value: "!within soon", wantErr: "positive duration"},
+		{value: "!json '{'", wantErr: "invalid !json value"},
+		{value: "!approx 1", wantErr: "unknown matcher !approx"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			_, err := ParseExpected([]byte("tables:\n  T:\n    columns:\n      C: " + tt.value + "\n"))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseExpected() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package spanwright
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"cloud.google.com/go/spanner"
+)
+
+// ErrNoRows is returned by QueryOne when the query returns no rows
+var ErrNoRows = errors.New("query returned no rows")
+
+// ErrStopQuery can be returned by a QueryEach callback to stop reading rows without error
+var ErrStopQuery = errors.New("stop query")
+
+// scalarStructPackages hold struct types that decode from a single column
+var scalarStructPackages = map[string]bool{
+	"time":                        true,
+	"math/big":                    true,
+	"cloud.google.com/go/civil":   true,
+	"cloud.google.com/go/spanner": true,
+}
+
+// decodesAsStruct reports whether rows are decoded into T field by field through
+// `spanner:"..."` tags rather than from a single column
+func decodesAsStruct(t reflect.Type) bool {
+	return t != nil && t.Kind() == reflect.Struct && !scalarStructPackages[t.PkgPath()]
+}
+
+// decodeRow decodes a row into T: structs by column name, anything else from the only column
+func decodeRow[T any](row *spanner.Row) (T, error) {
+	var value T
+	if decodesAsStruct(reflect.TypeOf(value)) {
+		return value, row.ToStruct(&value)
+	}
+
+	if row.Size() != 1 {
+		return value, fmt.Errorf("cannot decode %d columns into %T", row.Size(), value)
+	}
+	return value, row.Column(0, &value)
+}
+
+// QueryEach runs stmt in a single-use read-only transaction and calls fn with every row
+// decoded into T. Transient failures are retried with the manager's retry policy as long
+// as no row has been passed to fn yet. Returning ErrStopQuery from fn stops early.
+func QueryEach[T any](ctx context.Context, dm *DatabaseManager, stmt spanner.Statement, fn func(T) error) error {
+	return dm.queryWithRetry(ctx, stmt, nil, func(row *spanner.Row) error {
+		value, err := decodeRow[T](row)
+		if err != nil {
+			return fmt.Errorf("failed to decode row: %w", err)
+		}
+		return fn(value)
+	})
+}
+
+// QueryRows runs stmt and returns every row decoded into T, retrying transient failures
+func QueryRows[T any](ctx context.Context, dm *DatabaseManager, stmt spanner.Statement) ([]T, error) {
+	var rows []T
+	restart := func() { rows = rows[:0] }
+	err := dm.queryWithRetry(ctx, stmt, restart, func(row *spanner.Row) error {
+		value, err := decodeRow[T](row)
+		if err != nil {
+			return fmt.Errorf("failed to decode row: %w", err)
+		}
+		rows = append(rows, value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// QueryOne runs stmt and returns its first row decoded into T, or ErrNoRows
+func QueryOne[T any](ctx context.Context, dm *DatabaseManager, stmt spanner.Statement) (T, error) {
+	var result T
+	found := false
+	err := QueryEach(ctx, dm, stmt, func(value T) error {
+		result, found = value, true
+		return ErrStopQuery
+	})
+	if err != nil {
+		return result, err
+	}
+	if !found {
+		return result, ErrNoRows
+	}
+	return result, nil
+}
+
+// queryWithRetry runs stmt and calls fn for every row, retrying transient failures. restart
+// is called before each attempt so the caller can drop partial results; without it, a
+// failure after the first row is final because a retry would deliver rows twice. An error
+// from fn is never retried.
+func (dm *DatabaseManager) queryWithRetry(ctx context.Context, stmt spanner.Statement, restart func(), fn func(*spanner.Row) error) error {
+	ctx, cancel := dm.operationContext(ctx)
+	defer cancel()
+
+	var rowErr error
+	err := dm.config.Retry.Do(ctx, "Query", func(ctx context.Context, attempt int) error {
+		if restart != nil {
+			restart()
+		}
+
+		delivered := false
+		iter := dm.client.Single().Query(ctx, stmt)
+		err := iter.Do(func(row *spanner.Row) error {
+			delivered = true
+			if err := fn(row); err != nil {
+				rowErr = err
+				return err
+			}
+			return nil
+		})
+		if err == nil || rowErr != nil {
+			return nil
+		}
+		if delivered && restart == nil {
+			return &finalError{err: err}
+		}
+		return err
+	})
+
+	if rowErr != nil {
+		if errors.Is(rowErr, ErrStopQuery) {
+			return nil
+		}
+		return rowErr
+	}
+
+	var final *finalError
+	if errors.As(err, &final) {
+		return final.err
+	}
+	return err
+}
+
+// finalError stops RetryPolicy.Do from retrying the error it holds. It deliberately does
+// not unwrap, so the held error's gRPC code is hidden from the policy.
+type finalError struct{ err error }
+
+func (e *finalError) Error() string { return e.err.Error() }
@@ -0,0 +1,413 @@
+package spanwright
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+	"gopkg.in/yaml.v3"
+)
+
+// QuerySource is a SQL query and the database it runs on
+type QuerySource struct {
+	// Database is the logical name of the database; empty means the validated database
+	Database string
+	SQL      string
+	// Params are bound as @name in GoogleSQL and $1 (named p1) in PostgreSQL
+	Params map[string]interface{}
+}
+
+// QueryExpectation describes the expected result of a named query. Count checks the
+// number of rows; Scalar expects one row with one column; Rows, Order, Exact and Absent
+// work as they do for tables; SubsetOf requires every result row to also be returned by
+// another query, which may run on another database.
+type QueryExpectation struct {
+	Name string
+	Line int
+	QuerySource
+	Count     *int
+	HasScalar bool
+	Scalar    interface{}
+	Rows      []ExpectedRow
+	Order     RowOrder
+	Exact     bool
+	Absent    []ExpectedRow
+	SubsetOf  *QuerySource
+}
+
+// QueryCheckResult is the result of checking one query
+type QueryCheckResult struct {
+	Query      string     `json:"query"`
+	Database   string     `json:"database,omitempty"`
+	Passed     bool       `json:"passed"`
+	RowCount   int        `json:"row_count"`
+	Mismatches []Mismatch `json:"mismatches,omitempty"`
+}
+
+// parseQueryExpectation parses the entry of one query under queries
+func parseQueryExpectation(key, value *yaml.Node) (*QueryExpectation, error) {
+	query := &QueryExpectation{Name: key.Value, Line: key.Line, Order: RowsOrdered}
+	if value.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: query %s must be a mapping", value.Line, query.Name)
+	}
+
+	for i := 0; i < len(value.Content); i += 2 {
+		field, node := value.Content[i], value.Content[i+1]
+		var err error
+		switch field.Value {
+		case "database", "sql", "params":
+			err = parseQuerySourceField(&query.QuerySource, field, node)
+		case "count":
+			var count int
+			if node.Decode(&count) != nil || count < 0 {
+				err = fmt.Errorf("line %d: count must be a non-negative integer", node.Line)
+			}
+			query.Count = &count
+		case "scalar":
+			query.HasScalar = true
+			query.Scalar, err = parseExpectedValue(node)
+		case "rows":
+			query.Rows, err = parseExpectedRows(node, false)
+		case "order":
+			query.Order, err = parseRowOrder(node)
+		case "exact":
+			if node.Decode(&query.Exact) != nil {
+				err = fmt.Errorf("line %d: exact must be true or false", node.Line)
+			}
+		case "absent":
+			query.Absent, err = parseExpectedRows(node, true)
+		case "subset_of":
+			query.SubsetOf, err = parseQuerySource(node)
+		default:
+			err = fmt.Errorf("line %d: unknown key %q", field.Line, field.Value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("query %s: %w", query.Name, err)
+		}
+	}
+
+	if strings.TrimSpace(query.SQL) == "" {
+		return nil, fmt.Errorf("line %d: query %s needs sql", key.Line, query.Name)
+	}
+	if query.HasScalar && query.Rows != nil {
+		return nil, fmt.Errorf("line %d: query %s cannot have both scalar and rows", key.Line, query.Name)
+	}
+	return query, nil
+}
+
+// parseQuerySource parses a mapping with sql and optional database and params
+func parseQuerySource(node *yaml.Node) (*QuerySource, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: expected a mapping with sql", node.Line)
+	}
+
+	source := &QuerySource{}
+	for i := 0; i < len(node.Content); i += 2 {
+		if err := parseQuerySourceField(source, node.Content[i], node.Content[i+1]); err != nil {
+			return nil, err
+		}
+	}
+	if strings.TrimSpace(source.SQL) == "" {
+		return nil, fmt.Errorf("line %d: sql is required", node.Line)
+	}
+	return source, nil
+}
+
+// parseQuerySourceField parses the database, sql or params field of a query
+func parseQuerySourceField(source *QuerySource, field, node *yaml.Node) error {
+	switch field.Value {
+	case "database":
+		source.Database = node.Value
+	case "sql":
+		source.SQL = node.Value
+	case "params":
+		var params map[string]interface{}
+		if err := node.Decode(&params); err != nil {
+			return fmt.Errorf("line %d: params must be a mapping of names to values", node.Line)
+		}
+		converted, err := queryParams(params)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		source.Params = converted
+	default:
+		return fmt.Errorf("line %d: unknown key %q", field.Line, field.Value)
+	}
+	return nil
+}
+
+// queryParams converts YAML values into values Spanner can bind: integers become int64 and
+// lists become typed slices
+func queryParams(params map[string]interface{}) (map[string]interface{}, error) {
+	converted := make(map[string]interface{}, len(params))
+	for name, value := range params {
+		switch v := value.(type) {
+		case nil, string, bool, float64, int64:
+			converted[name] = v
+		case int:
+			converted[name] = int64(v)
+		case []interface{}:
+			list, err := queryParamList(v)
+			if err != nil {
+				return nil, fmt.Errorf("param %s: %w", name, err)
+			}
+			converted[name] = list
+		default:
+			return nil, fmt.Errorf("param %s: unsupported value %T", name, value)
+		}
+	}
+	return converted, nil
+}
+
+// queryParamList converts a list of values of one type into a typed slice
+func queryParamList(items []interface{}) (interface{}, error) {
+	if len(items) == 0 {
+		return []string{}, nil
+	}
+
+	var elem reflect.Type
+	switch items[0].(type) {
+	case string:
+		elem = reflect.TypeOf("")
+	case int, int64:
+		elem = reflect.TypeOf(int64(0))
+	case float64:
+		elem = reflect.TypeOf(float64(0))
+	case bool:
+		elem = reflect.TypeOf(false)
+	default:
+		return nil, fmt.Errorf("unsupported list item %T", items[0])
+	}
+
+	list := reflect.MakeSlice(reflect.SliceOf(elem), len(items), len(items))
+	for i, item := range items {
+		if n, ok := item.(int); ok {
+			item = int64(n)
+		}
+		v := reflect.ValueOf(item)
+		if v.Type() != elem {
+			return nil, fmt.Errorf("list items must all have the same type")
+		}
+		list.Index(i).Set(v)
+	}
+	return list.Interface(), nil
+}
+
+// queryResult holds the rows of a query, decoded like table rows
+type queryResult struct {
+	dump  *TableDump
+	types map[string]columnType
+	// ordered keeps the column types by position, since result columns may share a name
+	ordered []columnType
+}
+
+// readQuery runs a query and decodes every row with the fixture value of each column. Like
+// other reads it is bounded by the operation timeout and retried from the first row.
+func (dm *DatabaseManager) readQuery(ctx context.Context, name string, source QuerySource) (*queryResult, error) {
+	stmt := spanner.Statement{SQL: source.SQL, Params: source.Params}
+
+	var result *queryResult
+	restart := func() {
+		result = &queryResult{dump: &TableDump{Table: name, Rows: [][]interface{}{}}, types: map[string]columnType{}}
+	}
+	err := dm.queryWithRetry(ctx, stmt, restart, func(row *spanner.Row) error {
+		if result.ordered == nil {
+			for i, column := range row.ColumnNames() {
+				ct, err := resultColumnType(row.ColumnType(i))
+				if err != nil {
+					return fmt.Errorf("column %s: %w", column, err)
+				}
+				result.dump.Columns = append(result.dump.Columns, column)
+				result.ordered = append(result.ordered, ct)
+				result.types[column] = ct
+			}
+		}
+
+		values := make([]interface{}, len(result.ordered))
+		for i, ct := range result.ordered {
+			target := columnTarget(ct)
+			if err := row.Column(i, target); err != nil {
+				return fmt.Errorf("column %s: %w", result.dump.Columns[i], err)
+			}
+			value, err := fixtureValue(reflect.ValueOf(target).Elem().Interface())
+			if err != nil {
+				return fmt.Errorf("column %s: %w", result.dump.Columns[i], err)
+			}
+			values[i] = value
+		}
+		result.dump.Rows = append(result.dump.Rows, values)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("query %s failed: %w", name, err)
+	}
+	return result, nil
+}
+
+// resultColumnType maps the type of a result column to the kind it is decoded as
+func resultColumnType(t *sppb.Type) (columnType, error) {
+	var ct columnType
+	if t.GetCode() == sppb.TypeCode_ARRAY {
+		ct.array = true
+		t = t.GetArrayElementType()
+	}
+
+	switch t.GetCode() {
+	case sppb.TypeCode_BOOL:
+		ct.kind = kindBool
+	case sppb.TypeCode_INT64:
+		ct.kind = kindInt64
+	case sppb.TypeCode_FLOAT64:
+		ct.kind = kindFloat64
+	case sppb.TypeCode_FLOAT32:
+		ct.kind = kindFloat32
+	case sppb.TypeCode_TIMESTAMP:
+		ct.kind = kindTimestamp
+	case sppb.TypeCode_DATE:
+		ct.kind = kindDate
+	case sppb.TypeCode_STRING:
+		ct.kind = kindString
+	case sppb.TypeCode_BYTES:
+		ct.kind = kindBytes
+	case sppb.TypeCode_NUMERIC:
+		ct.kind = kindNumeric
+		if t.GetTypeAnnotation() == sppb.TypeAnnotationCode_PG_NUMERIC {
+			ct.kind = kindPGNumeric
+		}
+	case sppb.TypeCode_JSON:
+		ct.kind = kindJSON
+		if t.GetTypeAnnotation() == sppb.TypeAnnotationCode_PG_JSONB {
+			ct.kind = kindPGJsonB
+		}
+	default:
+		return ct, fmt.Errorf("unsupported result type %v", t.GetCode())
+	}
+	return ct, nil
+}
+
+// validateQuery runs a query and compares its result with the expectation
+func (v *Validator) validateQuery(ctx context.Context, query *QueryExpectation, now time.Time) *QueryCheckResult {
+	check := &QueryCheckResult{Query: query.Name, Database: query.Database}
+
+	dm, err := v.database(query.Database)
+	if err == nil {
+		var result *queryResult
+		if result, err = dm.readQuery(ctx, query.Name, query.QuerySource); err == nil {
+			check.RowCount = len(result.dump.Rows)
+			check.Mismatches = checkQueryResult(query, result, now)
+
+			if query.SubsetOf != nil {
+				check.Mismatches = append(check.Mismatches, v.checkSubset(ctx, query, result)...)
+			}
+		}
+	}
+	if err != nil {
+		check.Mismatches = append(check.Mismatches, Mismatch{Message: err.Error()})
+	}
+
+	check.Passed = len(check.Mismatches) == 0
+	return check
+}
+
+// checkQueryResult compares a query result with the count, scalar and row expectations
+func checkQueryResult(query *QueryExpectation, result *queryResult, now time.Time) []Mismatch {
+	var mismatches []Mismatch
+	rowCount := len(result.dump.Rows)
+	if query.Count != nil && *query.Count != rowCount {
+		mismatches = append(mismatches, Mismatch{
+			Expected: *query.Count,
+			Actual:   rowCount,
+			Message:  fmt.Sprintf("expected %d rows, found %d", *query.Count, rowCount),
+		})
+	}
+
+	rows := newRowMatcher(result.dump, result.types, nil, now)
+	if query.HasScalar {
+		if rowCount != 1 || len(result.dump.Columns) != 1 {
+			mismatches = append(mismatches, Mismatch{
+				Message: fmt.Sprintf("scalar expects one row with one column, got %d rows with %d columns", rowCount, len(result.dump.Columns)),
+			})
+		} else {
+			mismatches = append(mismatches, rows.match(0, ExpectedRow{{Column: result.dump.Columns[0], Value: query.Scalar}})...)
+		}
+	}
+
+	claimed := make([]bool, rowCount)
+	if query.Order == RowsSet {
+		mismatches = append(mismatches, rows.matchSet(query.Rows, claimed)...)
+	} else {
+		mismatches = append(mismatches, rows.matchOrdered(query.Rows, claimed)...)
+	}
+	for _, absent := range query.Absent {
+		mismatches = append(mismatches, rows.matchAbsent(absent)...)
+	}
+	if query.Exact {
+		for i := range result.dump.Rows {
+			if !claimed[i] {
+				mismatches = append(mismatches, Mismatch{Row: i + 1, Message: "unexpected row"})
+			}
+		}
+	}
+	return mismatches
+}
+
+// checkSubset reports every result row that the subset_of query does not also return
+func (v *Validator) checkSubset(ctx context.Context, query *QueryExpectation, result *queryResult) []Mismatch {
+	dm, err := v.database(query.SubsetOf.Database)
+	if err != nil {
+		return []Mismatch{{Message: "subset_of: " + err.Error()}}
+	}
+	superset, err := dm.readQuery(ctx, query.Name+" subset_of", *query.SubsetOf)
+	if err != nil {
+		return []Mismatch{{Message: "subset_of: " + err.Error()}}
+	}
+	if len(superset.ordered) != len(result.ordered) {
+		return []Mismatch{{Message: fmt.Sprintf("subset_of returns %d columns, but the query returns %d", len(superset.ordered), len(result.ordered))}}
+	}
+
+	known := map[string]bool{}
+	for _, row := range superset.dump.Rows {
+		key, err := canonicalRow(row, superset.ordered)
+		if err != nil {
+			return []Mismatch{{Message: "subset_of: " + err.Error()}}
+		}
+		known[key] = true
+	}
+
+	var mismatches []Mismatch
+	for i, row := range result.dump.Rows {
+		key, err := canonicalRow(row, result.ordered)
+		if err != nil {
+			return append(mismatches, Mismatch{Row: i + 1, Message: err.Error()})
+		}
+		if !known[key] {
+			mismatches = append(mismatches, Mismatch{Row: i + 1, Actual: row, Message: "row " + formatKey(row) + " is not returned by subset_of"})
+		}
+	}
+	return mismatches
+}
+
+// canonicalRow returns text that is equal for rows with equal values
+func canonicalRow(row []interface{}, types []columnType) (string, error) {
+	parts := make([]string, len(row))
+	for i, value := range row {
+		if value == nil {
+			parts[i] = "NULL"
+			continue
+		}
+		if types[i].array {
+			parts[i] = formatValue(value)
+			continue
+		}
+		text, err := canonicalValue(value, types[i].kind)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = fmt.Sprintf("%q", text)
+	}
+	return strings.Join(parts, ","), nil
+}
@@ -0,0 +1,185 @@
+package spanwright
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+)
+
+func TestParseQueryExpectation(t *testing.T) {
+	content := `tables: {}
+queries:
+  balance_matches_orders:
+    sql: SELECT COUNT(*) FROM Users u WHERE u.Balance != (SELECT SUM(Amount) FROM Orders o WHERE o.UserID = u.UserID)
+    scalar: 0
+  active_users:
+    sql: SELECT UserID FROM Users WHERE Status = @status AND UserID IN UNNEST(@ids)
+    params: {status: 1, ids: [user-001, user-002]}
+    order: set
+    rows:
+      - {UserID: user-001}
+  logs_reference_users:
+    database: secondary
+    sql: SELECT DISTINCT UserID FROM UserLogs
+    subset_of:
+      database: primary
+      sql: SELECT UserID FROM Users
+`
+	state, err := ParseExpected([]byte(content))
+	if err != nil {
+		t.Fatalf("ParseExpected() error = %v", err)
+	}
+	if len(state.Queries) != 3 {
+		t.Fatalf("ParseExpected() queries = %d, want 3", len(state.Queries))
+	}
+
+	balance := state.Queries[0]
+	if !balance.HasScalar || balance.Scalar != 0 || balance.Name != "balance_matches_orders" {
+		t.Errorf("scalar query = %+v", balance)
+	}
+
+	active := state.Queries[1]
+	wantParams := map[string]interface{}{"status": int64(1), "ids": []string{"user-001", "user-002"}}
+	if !reflect.DeepEqual(active.Params, wantParams) || active.Order != RowsSet || len(active.Rows) != 1 {
+		t.Errorf("rows query = %+v", active)
+	}
+
+	logs := state.Queries[2]
+	if logs.Database != "secondary" || logs.SubsetOf == nil || logs.SubsetOf.Database != "primary" {
+		t.Errorf("subset query = %+v", logs)
+	}
+	if got, want := state.Databases(), []string{"secondary", "primary"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Databases() = %v, want %v", got, want)
+	}
+}
+
+func TestParseQueryExpectationErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "missing sql", content: "queries:\n  q:\n    scalar: 1\n", wantErr: "needs sql"},
+		{name: "scalar and rows", content: "queries:\n  q:\n    sql: SELECT 1\n    scalar: 1\n    rows: []\n", wantErr: "both scalar and rows"},
+		{name: "mixed list param", content: "queries:\n  q:\n    sql: SELECT 1\n    params: {ids: [1, a]}\n", wantErr: "same type"},
+		{name: "subset without sql", content: "queries:\n  q:\n    sql: SELECT 1\n    subset_of: {database: primary}\n", wantErr: "sql is required"},
+		{name: "unknown key", content: "queries:\n  q:\n    sql: SELECT 1\n    expect: 1\n", wantErr: `unknown key "expect"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseExpected([]byte(tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseExpected() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckQueryResult(t *testing.T) {
+	result := &queryResult{
+		dump: &TableDump{
+			Table:   "q",
+			Columns: []string{"UserID", "Total"},
+			Rows:    [][]interface{}{{"user-001", "12.5"}, {"user-002", "3"}},
+		},
+		types:   map[string]columnType{"UserID": {kind: kindString}, "Total": {kind: kindNumeric}},
+		ordered: []columnType{{kind: kindString}, {kind: kindNumeric}},
+	}
+
+	one := 1
+	tests := []struct {
+		name  string
+		query *QueryExpectation
+		want  []string
+	}{
+		{
+			name:  "set rows",
+			query: &QueryExpectation{Order: RowsSet, Exact: true, Rows: []ExpectedRow{{{Column: "Total", Value: 3}}, {{Column: "Total", Value: 12.5}}}},
+		},
+		{
+			name:  "count and absent",
+			query: &QueryExpectation{Count: &one, Absent: []ExpectedRow{{{Column: "UserID", Value: "user-002"}}}},
+			want:  []string{"expected 1 rows, found 2", "row 2 row must not exist: {UserID: \"user-002\"}"},
+		},
+		{
+			name:  "scalar needs one value",
+			query: &QueryExpectation{HasScalar: true, Scalar: 1},
+			want:  []string{"scalar expects one row with one column, got 2 rows with 2 columns"},
+		},
+		{
+			name:  "exact ordered",
+			query: &QueryExpectation{Exact: true, Rows: []ExpectedRow{{{Column: "UserID", Value: "user-001"}}}},
+			want:  []string{"row 2 unexpected row"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, mismatch := range checkQueryResult(tt.query, result, time.Now()) {
+				got = append(got, mismatch.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("checkQueryResult() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+
+	scalar := &queryResult{
+		dump:    &TableDump{Columns: []string{""}, Rows: [][]interface{}{{int64(0)}}},
+		types:   map[string]columnType{"": {kind: kindInt64}},
+		ordered: []columnType{{kind: kindInt64}},
+	}
+	if mismatches := checkQueryResult(&QueryExpectation{HasScalar: true, Scalar: 0}, scalar, time.Now()); len(mismatches) != 0 {
+		t.Errorf("checkQueryResult() scalar = %v", mismatches)
+	}
+}
+
+func TestCanonicalRow(t *testing.T) {
+	types := []columnType{{kind: kindNumeric}, {kind: kindTimestamp}}
+	a, err := canonicalRow([]interface{}{"1.50", "2024-01-01T09:00:00+09:00"}, types)
+	if err != nil {
+		t.Fatalf("canonicalRow() error = %v", err)
+	}
+	b, err := canonicalRow([]interface{}{"1.5", "2024-01-01T00:00:00Z"}, types)
+	if err != nil {
+		t.Fatalf("canonicalRow() error = %v", err)
+	}
+	if a != b {
+		t.Errorf("canonicalRow() = %s and %s, want equal", a, b)
+	}
+}
+
+func TestResultColumnType(t *testing.T) {
+	tests := []struct {
+		name    string
+		typ     *sppb.Type
+		want    columnType
+		wantErr bool
+	}{
+		{name: "int64", typ: &sppb.Type{Code: sppb.TypeCode_INT64}, want: columnType{kind: kindInt64}},
+		{name: "pg numeric", typ: &sppb.Type{Code: sppb.TypeCode_NUMERIC, TypeAnnotation: sppb.TypeAnnotationCode_PG_NUMERIC}, want: columnType{kind: kindPGNumeric}},
+		{
+			name: "array of strings",
+			typ:  &sppb.Type{Code: sppb.TypeCode_ARRAY, ArrayElementType: &sppb.Type{Code: sppb.TypeCode_STRING}},
+			want: columnType{kind: kindString, array: true},
+		},
+		{name: "struct", typ: &sppb.Type{Code: sppb.TypeCode_STRUCT}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resultColumnType(tt.typ)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resultColumnType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("resultColumnType() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package spanwright
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+)
+
+func TestDecodesAsStruct(t *testing.T) {
+	type tableRow struct {
+		Name string `spanner:"table_name"`
+	}
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  bool
+	}{
+		{name: "tagged struct", value: tableRow{}, want: true},
+		{name: "string", value: "", want: false},
+		{name: "int64", value: int64(0), want: false},
+		{name: "timestamp", value: time.Time{}, want: false},
+		{name: "date", value: civil.Date{}, want: false},
+		{name: "numeric", value: big.Rat{}, want: false},
+		{name: "nullable string", value: spanner.NullString{}, want: false},
+		{name: "pointer", value: &tableRow{}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodesAsStruct(reflect.TypeOf(tt.value)); got != tt.want {
+				t.Errorf("decodesAsStruct(%T) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
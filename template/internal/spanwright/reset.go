@@ -0,0 +1,143 @@
+package spanwright
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	database "cloud.google.com/go/spanner/admin/database/apiv1"
+	"cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+)
+
+// ResetMode selects how Reset clears a database
+type ResetMode string
+
+const (
+	// ResetAll deletes every row of every table, children first
+	ResetAll ResetMode = "all"
+	// ResetSeeded deletes only the rows recorded as seeded, children first
+	ResetSeeded ResetMode = "seeded"
+	// ResetRecreate drops the database and recreates it with the same dialect and schema
+	ResetRecreate ResetMode = "recreate"
+)
+
+// ParseResetMode validates a reset mode name
+func ParseResetMode(value string) (ResetMode, error) {
+	switch mode := ResetMode(value); mode {
+	case ResetAll, ResetSeeded, ResetRecreate:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid reset mode %q: expected %s, %s or %s", value, ResetAll, ResetSeeded, ResetRecreate)
+}
+
+// Reset clears the database for the next test run and forgets its seeded rows. Rows are
+// deleted children first so interleaved and referencing rows go before their parents.
+// ResetSeeded fails without deleting anything if a seeded row cannot be identified, and
+// fails when rows added since reference a seeded row without ON DELETE CASCADE.
+//
+// After ResetRecreate the manager's client still holds sessions of the dropped database;
+// create a new DatabaseManager to read or write rows.
+func (dm *DatabaseManager) Reset(ctx context.Context, mode ResetMode) error {
+	var err error
+	switch mode {
+	case ResetAll:
+		err = dm.Truncate(ctx)
+	case ResetSeeded:
+		err = dm.deleteSeeded(ctx)
+	case ResetRecreate:
+		err = dm.recreate(ctx)
+	default:
+		_, err = ParseResetMode(string(mode))
+	}
+	if err != nil {
+		return err
+	}
+
+	dm.clearSeeded()
+	return nil
+}
+
+// deleteSeeded deletes the rows recorded as seeded
+func (dm *DatabaseManager) deleteSeeded(ctx context.Context) error {
+	ctx, cancel := dm.operationContext(ctx)
+	defer cancel()
+
+	seeded := dm.SeededRows()
+	if seeded == nil {
+		log.Printf("🧹 No seeded rows recorded for %s", dm.config.DatabaseID)
+		return nil
+	}
+
+	schema, err := dm.DescribeSchema(ctx)
+	if err != nil {
+		return err
+	}
+	order, err := tableOrder(schema)
+	if err != nil {
+		return err
+	}
+
+	deletes, err := seededDeletes(seeded, schema, order)
+	if err != nil {
+		return err
+	}
+	if err := dm.applyInBatches(ctx, deletes); err != nil {
+		return fmt.Errorf("failed to delete seeded rows of %s: %w", dm.config.DatabaseID, err)
+	}
+
+	log.Printf("🧹 Deleted %d seeded rows from %s", len(deletes), dm.config.DatabaseID)
+	return nil
+}
+
+// recreate drops the database and creates it again from the DDL it had, which also
+// restarts its sequences
+func (dm *DatabaseManager) recreate(ctx context.Context) error {
+	ctx, cancel := dm.operationContext(ctx)
+	defer cancel()
+
+	dialect, exists, err := dm.lookupDatabase(ctx)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("database %s does not exist", dm.config.DatabaseID)
+	}
+
+	statements, err := dm.databaseDDL(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := dm.dropDatabase(ctx); err != nil {
+		return err
+	}
+	if _, err := dm.ensureDatabase(ctx, dialect); err != nil {
+		return err
+	}
+	if err := dm.updateDDL(ctx, statements, "the previous schema of "+dm.config.DatabaseID); err != nil {
+		return err
+	}
+
+	log.Printf("🧹 Recreated %s", dm.config.DatabaseID)
+	return nil
+}
+
+// databaseDDL returns the DDL statements that recreate the schema of the database
+func (dm *DatabaseManager) databaseDDL(ctx context.Context) ([]DDLStatement, error) {
+	adminClient, err := database.NewDatabaseAdminClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create database admin client: %w", err)
+	}
+	defer adminClient.Close()
+
+	resp, err := adminClient.GetDatabaseDdl(ctx, &databasepb.GetDatabaseDdlRequest{Database: dm.config.DatabasePath()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the schema of %s: %w", dm.config.DatabaseID, err)
+	}
+
+	statements := make([]DDLStatement, 0, len(resp.Statements))
+	for _, sql := range resp.Statements {
+		statements = append(statements, DDLStatement{SQL: sql})
+	}
+	return statements, nil
+}
@@ -0,0 +1,244 @@
+package spanwright
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"google.golang.org/grpc/codes"
+)
+
+// Clock abstracts time so retries can be tested deterministically
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the wall clock
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// RetryAttempt describes a failed attempt, as reported to RetryPolicy.OnAttempt
+type RetryAttempt struct {
+	Operation string
+	Attempt   int
+	Err       error
+	// Elapsed is the time since the first attempt started
+	Elapsed time.Duration
+	// Retry reports whether another attempt follows, after Delay
+	Retry bool
+	Delay time.Duration
+}
+
+// RetryPolicy controls how transient Spanner errors are retried
+type RetryPolicy struct {
+	// MaxAttempts includes the first attempt; zero uses the default
+	MaxAttempts int
+	// InitialDelay is the wait before the second attempt
+	InitialDelay time.Duration
+	// MaxDelay caps a single wait; zero means no cap
+	MaxDelay time.Duration
+	// Multiplier grows the delay after each attempt; zero uses the default
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction, between 0 and 1
+	Jitter float64
+	// MaxElapsed stops retrying once another wait would exceed it; zero means no limit
+	MaxElapsed time.Duration
+	// RetryableCodes lists the gRPC codes that are retried; nil uses the default
+	RetryableCodes []codes.Code
+
+	// OnAttempt, if set, observes every failed attempt
+	OnAttempt func(RetryAttempt)
+	// Clock, if set, replaces the wall clock
+	Clock Clock
+	// Rand, if set, replaces math/rand as the jitter source; it returns values in [0, 1)
+	Rand func() float64
+}
+
+// DefaultRetryPolicy retries the codes Spanner documents as transient, including Aborted
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:  5,
+	InitialDelay: 100 * time.Millisecond,
+	MaxDelay:     5 * time.Second,
+	Multiplier:   2,
+	Jitter:       0.2,
+	MaxElapsed:   30 * time.Second,
+	RetryableCodes: []codes.Code{
+		codes.Aborted,
+		codes.Unavailable,
+		codes.ResourceExhausted,
+		codes.Internal,
+		codes.DeadlineExceeded,
+	},
+}
+
+// retryCodeNames maps the names accepted in RETRY_CODES to gRPC codes
+var retryCodeNames = map[string]codes.Code{
+	"CANCELLED":           codes.Canceled,
+	"UNKNOWN":             codes.Unknown,
+	"DEADLINE_EXCEEDED":   codes.DeadlineExceeded,
+	"NOT_FOUND":           codes.NotFound,
+	"ALREADY_EXISTS":      codes.AlreadyExists,
+	"RESOURCE_EXHAUSTED":  codes.ResourceExhausted,
+	"FAILED_PRECONDITION": codes.FailedPrecondition,
+	"ABORTED":             codes.Aborted,
+	"INTERNAL":            codes.Internal,
+	"UNAVAILABLE":         codes.Unavailable,
+}
+
+// ParseRetryCodes parses gRPC code names such as ABORTED or UNAVAILABLE
+func ParseRetryCodes(names []string) ([]codes.Code, error) {
+	var parsed []codes.Code
+	for _, name := range names {
+		code, ok := retryCodeNames[strings.ToUpper(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown or non-retryable gRPC code %q", name)
+		}
+		parsed = append(parsed, code)
+	}
+	return parsed, nil
+}
+
+// Validate checks that the policy's settings are in range
+func (p RetryPolicy) Validate() error {
+	if p.MaxAttempts < 0 {
+		return fmt.Errorf("max attempts must not be negative, got %d", p.MaxAttempts)
+	}
+	if p.InitialDelay < 0 || p.MaxDelay < 0 || p.MaxElapsed < 0 {
+		return fmt.Errorf("retry delays must not be negative")
+	}
+	if p.Multiplier != 0 && p.Multiplier < 1 {
+		return fmt.Errorf("multiplier must be at least 1, got %v", p.Multiplier)
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("jitter must be between 0 and 1, got %v", p.Jitter)
+	}
+	return nil
+}
+
+// withDefaults fills unset fields from DefaultRetryPolicy
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts < 1 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.Multiplier == 0 {
+		p.Multiplier = DefaultRetryPolicy.Multiplier
+	}
+	if p.RetryableCodes == nil {
+		p.RetryableCodes = DefaultRetryPolicy.RetryableCodes
+	}
+	if p.Clock == nil {
+		p.Clock = realClock{}
+	}
+	if p.Rand == nil {
+		p.Rand = rand.Float64
+	}
+	return p
+}
+
+// IsRetryable reports whether err carries one of the policy's retryable codes.
+// Context cancellation and deadlines are never retried.
+func (p RetryPolicy) IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	code := spanner.ErrCode(err)
+	for _, retryable := range p.withDefaults().RetryableCodes {
+		if code == retryable {
+			return true
+		}
+	}
+	return false
+}
+
+// Delay returns the wait after the given failed attempt: InitialDelay grown by Multiplier
+// per attempt, capped at MaxDelay, then randomized by Jitter
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	p = p.withDefaults()
+
+	delay := float64(p.InitialDelay) * math.Pow(p.Multiplier, float64(attempt-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		delay *= 1 - p.Jitter + 2*p.Jitter*p.Rand()
+	}
+	return time.Duration(delay)
+}
+
+// Do runs fn until it succeeds, fails with a non-retryable error, runs out of attempts or
+// elapsed time, or ctx is done
+func (p RetryPolicy) Do(ctx context.Context, operation string, fn func(context.Context, int) error) error {
+	p = p.withDefaults()
+	start := p.Clock.Now()
+
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			if lastErr != nil {
+				return fmt.Errorf("%s stopped after %d attempts: %w (last error: %v)", operation, attempt-1, err, lastErr)
+			}
+			return err
+		}
+
+		err := fn(ctx, attempt)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+
+		report := RetryAttempt{Operation: operation, Attempt: attempt, Err: err, Elapsed: p.Clock.Now().Sub(start)}
+
+		// Errors caused by our own deadline or cancellation are never transient
+		if ctx.Err() != nil || !p.IsRetryable(err) {
+			p.observe(report)
+			return err
+		}
+
+		if attempt >= p.MaxAttempts {
+			p.observe(report)
+			return fmt.Errorf("max retry attempts (%d) exceeded: %w", p.MaxAttempts, err)
+		}
+
+		delay := p.Delay(attempt)
+		if p.MaxElapsed > 0 && report.Elapsed+delay > p.MaxElapsed {
+			p.observe(report)
+			return fmt.Errorf("retry time budget (%v) exhausted after %d attempts: %w", p.MaxElapsed, attempt, err)
+		}
+
+		report.Retry, report.Delay = true, delay
+		p.observe(report)
+		log.Printf("⚠️ %s failed on attempt %d/%d, retrying in %v: %v", operation, attempt, p.MaxAttempts, delay, err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s stopped after %d attempts: %w (last error: %v)", operation, attempt, ctx.Err(), lastErr)
+		case <-p.Clock.After(delay):
+		}
+	}
+}
+
+// observe reports a failed attempt to the OnAttempt hook
+func (p RetryPolicy) observe(attempt RetryAttempt) {
+	if p.OnAttempt != nil {
+		p.OnAttempt(attempt)
+	}
+}
+
+// WithRetry executes a function with DefaultRetryPolicy
+func WithRetry(ctx context.Context, operation string, fn func(context.Context, int) error) error {
+	return DefaultRetryPolicy.Do(ctx, operation, fn)
+}
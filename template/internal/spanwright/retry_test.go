@@ -0,0 +1,168 @@
+package spanwright
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeClock advances only when a retry waits on it
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := RetryPolicy{InitialDelay: 100 * time.Millisecond, MaxDelay: time.Second, Multiplier: 2}
+
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, w := range want {
+		if got := policy.Delay(i + 1); got != w {
+			t.Errorf("Delay(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+
+	policy.Jitter = 0.5
+	policy.Rand = func() float64 { return 0 }
+	if got := policy.Delay(1); got != 50*time.Millisecond {
+		t.Errorf("Delay(1) with minimum jitter = %v, want 50ms", got)
+	}
+	policy.Rand = func() float64 { return 0.999999 }
+	if got := policy.Delay(1); got < 149*time.Millisecond || got > 150*time.Millisecond {
+		t.Errorf("Delay(1) with maximum jitter = %v, want about 150ms", got)
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	aborted := status.Error(codes.Aborted, "transaction aborted")
+	invalid := status.Error(codes.InvalidArgument, "bad statement")
+
+	tests := []struct {
+		name       string
+		policy     RetryPolicy
+		errs       []error
+		wantCalls  int
+		wantSleeps []time.Duration
+		wantErr    error
+	}{
+		{
+			name:       "aborted is retried until success",
+			policy:     RetryPolicy{MaxAttempts: 5, InitialDelay: 10 * time.Millisecond, Multiplier: 2},
+			errs:       []error{aborted, aborted, nil},
+			wantCalls:  3,
+			wantSleeps: []time.Duration{10 * time.Millisecond, 20 * time.Millisecond},
+		},
+		{
+			name:       "non-retryable code stops immediately",
+			policy:     RetryPolicy{MaxAttempts: 5, InitialDelay: 10 * time.Millisecond},
+			errs:       []error{invalid},
+			wantCalls:  1,
+			wantSleeps: nil,
+			wantErr:    invalid,
+		},
+		{
+			name:       "max attempts exceeded",
+			policy:     RetryPolicy{MaxAttempts: 3, InitialDelay: 10 * time.Millisecond, Multiplier: 1},
+			errs:       []error{aborted, aborted, aborted, aborted},
+			wantCalls:  3,
+			wantSleeps: []time.Duration{10 * time.Millisecond, 10 * time.Millisecond},
+			wantErr:    aborted,
+		},
+		{
+			name:       "max elapsed budget",
+			policy:     RetryPolicy{MaxAttempts: 10, InitialDelay: 100 * time.Millisecond, Multiplier: 2, MaxElapsed: 500 * time.Millisecond},
+			errs:       []error{aborted, aborted, aborted, aborted, aborted},
+			wantCalls:  3,
+			wantSleeps: []time.Duration{100 * time.Millisecond, 200 * time.Millisecond},
+			wantErr:    aborted,
+		},
+		{
+			name:       "per-policy retryable codes",
+			policy:     RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond, RetryableCodes: []codes.Code{codes.InvalidArgument}},
+			errs:       []error{invalid, nil},
+			wantCalls:  2,
+			wantSleeps: []time.Duration{time.Millisecond},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Unix(0, 0)}
+			var attempts []RetryAttempt
+
+			policy := tt.policy
+			policy.Clock = clock
+			policy.OnAttempt = func(a RetryAttempt) { attempts = append(attempts, a) }
+
+			calls := 0
+			err := policy.Do(context.Background(), "test", func(ctx context.Context, attempt int) error {
+				calls++
+				if attempt != calls {
+					t.Errorf("attempt = %d, want %d", attempt, calls)
+				}
+				return tt.errs[calls-1]
+			})
+
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Do() error = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Do() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("Do() called fn %d times, want %d", calls, tt.wantCalls)
+			}
+			if !reflect.DeepEqual(clock.sleeps, tt.wantSleeps) {
+				t.Errorf("Do() slept %v, want %v", clock.sleeps, tt.wantSleeps)
+			}
+
+			// Every failed attempt is observed, and only the ones that are followed by
+			// another attempt report a delay
+			failed := calls
+			if err == nil {
+				failed--
+			}
+			if len(attempts) != failed {
+				t.Fatalf("OnAttempt called %d times, want %d", len(attempts), failed)
+			}
+			for i, a := range attempts {
+				retried := i < len(clock.sleeps)
+				if a.Retry != retried {
+					t.Errorf("attempt %d Retry = %v, want %v", a.Attempt, a.Retry, retried)
+				}
+				if retried && a.Delay != clock.sleeps[i] {
+					t.Errorf("attempt %d Delay = %v, want %v", a.Attempt, a.Delay, clock.sleeps[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseRetryCodes(t *testing.T) {
+	got, err := ParseRetryCodes([]string{"aborted", " UNAVAILABLE "})
+	if err != nil {
+		t.Fatalf("ParseRetryCodes() error = %v", err)
+	}
+	if want := []codes.Code{codes.Aborted, codes.Unavailable}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseRetryCodes() = %v, want %v", got, want)
+	}
+
+	if _, err := ParseRetryCodes([]string{"OK"}); err == nil {
+		t.Error("ParseRetryCodes(OK) expected error")
+	}
+}
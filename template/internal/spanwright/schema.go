@@ -0,0 +1,309 @@
+package spanwright
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	database "cloud.google.com/go/spanner/admin/database/apiv1"
+	"cloud.google.com/go/spanner/admin/database/apiv1/databasepb"
+	instance "cloud.google.com/go/spanner/admin/instance/apiv1"
+	"cloud.google.com/go/spanner/admin/instance/apiv1/instancepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// emulatorInstanceConfig is the only instance configuration offered by the emulator
+const emulatorInstanceConfig = "emulator-config"
+
+// InstancePath returns the full instance path
+func (dc *DatabaseConfig) InstancePath() string {
+	return "projects/" + dc.ProjectID + "/instances/" + dc.InstanceID
+}
+
+// ApplySchema creates the instance and database when missing, then applies every DDL
+// statement found in the *.sql files of schemaPath. Existing rows and objects are left as
+// they are, so the DDL must apply on top of them; PrepareSchema starts from an empty
+// database instead.
+func (dm *DatabaseManager) ApplySchema(ctx context.Context, schemaPath string) error {
+	ctx, cancel := dm.operationContext(ctx)
+	defer cancel()
+
+	if err := dm.ensureInstance(ctx); err != nil {
+		return err
+	}
+
+	dialect, err := dm.ensureDatabase(ctx, dm.config.Dialect)
+	if err != nil {
+		return err
+	}
+
+	ddlStatements, err := ReadSchemaFiles(schemaPath, dialect)
+	if err != nil {
+		return err
+	}
+	return dm.updateDDL(ctx, ddlStatements, schemaPath)
+}
+
+// SchemaAction is what PrepareSchema did to give a database its schema
+type SchemaAction string
+
+const (
+	// SchemaCreated means the database did not exist and was created with the schema
+	SchemaCreated SchemaAction = "created"
+	// SchemaRebuilt means the database was dropped and recreated because its schema changed
+	// or was not known
+	SchemaRebuilt SchemaAction = "rebuilt"
+	// SchemaTruncated means the database already had the schema and only its rows were deleted
+	SchemaTruncated SchemaAction = "truncated"
+)
+
+// PrepareSchema leaves the database with the schema of schemaPath and no rows. When the
+// cache records that an existing database was built from the same schema, only its rows
+// are deleted; otherwise an existing database is dropped and recreated, which is logged with
+// the reason. A nil cache always rebuilds existing databases.
+func (dm *DatabaseManager) PrepareSchema(ctx context.Context, schemaPath string, cache *SchemaCache) (SchemaAction, error) {
+	ctx, cancel := dm.operationContext(ctx)
+	defer cancel()
+
+	if err := dm.ensureInstance(ctx); err != nil {
+		return "", err
+	}
+
+	existing, exists, err := dm.lookupDatabase(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// A rebuilt database keeps its dialect unless another one is configured
+	dialect := dm.config.Dialect
+	if dialect == "" {
+		dialect = DialectGoogleSQL
+		if exists {
+			dialect = existing
+		}
+	}
+
+	ddlStatements, err := ReadSchemaFiles(schemaPath, dialect)
+	if err != nil {
+		return "", err
+	}
+	hash := SchemaHash(ddlStatements, dialect)
+	key := dm.config.schemaCacheKey()
+
+	action := SchemaCreated
+	if exists {
+		reason := rebuildReason(cache, key, hash, existing, dialect)
+		if reason == "" {
+			if err := dm.Truncate(ctx); err != nil {
+				return "", err
+			}
+			return SchemaTruncated, nil
+		}
+		log.Printf("⚠️ Dropping existing database %s and all of its rows: %s", dm.config.DatabaseID, reason)
+
+		// Forget the old hash first so an interrupted rebuild is not taken for a finished one
+		if cache != nil {
+			if err := cache.Forget(key); err != nil {
+				return "", err
+			}
+		}
+		if err := dm.dropDatabase(ctx); err != nil {
+			return "", err
+		}
+		action = SchemaRebuilt
+	}
+
+	if _, err := dm.ensureDatabase(ctx, dialect); err != nil {
+		return "", err
+	}
+	if err := dm.updateDDL(ctx, ddlStatements, schemaPath); err != nil {
+		return "", err
+	}
+
+	if cache != nil {
+		if err := cache.Record(key, hash); err != nil {
+			return "", err
+		}
+	}
+	return action, nil
+}
+
+// rebuildReason explains why an existing database cannot keep its schema, or returns "" when
+// the cache records that it was built with the same one
+func rebuildReason(cache *SchemaCache, key, hash string, existing, dialect Dialect) string {
+	if cache == nil {
+		return "no schema cache is used, so every existing database is rebuilt"
+	}
+	if existing != dialect {
+		return fmt.Sprintf("its dialect is %s, not %s", existing, dialect)
+	}
+	switch cache.Lookup(key) {
+	case hash:
+		return ""
+	case "":
+		return fmt.Sprintf("schema cache %s has no entry for it, so its schema is unknown", cache.path)
+	default:
+		return "its schema changed since it was built"
+	}
+}
+
+// updateDDL applies DDL statements read from schemaPath to the database
+func (dm *DatabaseManager) updateDDL(ctx context.Context, ddlStatements []DDLStatement, schemaPath string) error {
+	statements := make([]string, 0, len(ddlStatements))
+	for _, statement := range ddlStatements {
+		statements = append(statements, statement.SQL)
+	}
+
+	if len(statements) == 0 {
+		log.Printf("⚠️ No DDL statements found in %s", schemaPath)
+		return nil
+	}
+
+	adminClient, err := database.NewDatabaseAdminClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create database admin client: %w", err)
+	}
+	defer adminClient.Close()
+
+	op, err := adminClient.UpdateDatabaseDdl(ctx, &databasepb.UpdateDatabaseDdlRequest{
+		Database:   dm.config.DatabasePath(),
+		Statements: statements,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to submit DDL for %s: %w", dm.config.DatabaseID, err)
+	}
+	if err := op.Wait(ctx); err != nil {
+		return fmt.Errorf("failed to apply DDL to %s: %w", dm.config.DatabaseID, err)
+	}
+
+	log.Printf("📄 Applied %d DDL statements to %s", len(statements), dm.config.DatabaseID)
+	return nil
+}
+
+// ensureInstance creates the emulator instance if it does not exist yet
+func (dm *DatabaseManager) ensureInstance(ctx context.Context) error {
+	adminClient, err := instance.NewInstanceAdminClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create instance admin client: %w", err)
+	}
+	defer adminClient.Close()
+
+	instancePath := dm.config.InstancePath()
+	_, err = adminClient.GetInstance(ctx, &instancepb.GetInstanceRequest{Name: instancePath})
+	if err == nil {
+		return nil
+	}
+	if status.Code(err) != codes.NotFound {
+		return fmt.Errorf("failed to look up instance %s: %w", dm.config.InstanceID, err)
+	}
+
+	projectPath := "projects/" + dm.config.ProjectID
+	op, err := adminClient.CreateInstance(ctx, &instancepb.CreateInstanceRequest{
+		Parent:     projectPath,
+		InstanceId: dm.config.InstanceID,
+		Instance: &instancepb.Instance{
+			Config:      projectPath + "/instanceConfigs/" + emulatorInstanceConfig,
+			DisplayName: dm.config.InstanceID,
+			NodeCount:   1,
+		},
+	})
+	if err == nil {
+		_, err = op.Wait(ctx)
+	}
+	if status.Code(err) == codes.AlreadyExists {
+		// Another process created it first
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create instance %s: %w", dm.config.InstanceID, err)
+	}
+
+	log.Printf("Created instance %s", dm.config.InstanceID)
+	return nil
+}
+
+// lookupDatabase reports whether the database exists and, if so, its dialect
+func (dm *DatabaseManager) lookupDatabase(ctx context.Context) (Dialect, bool, error) {
+	adminClient, err := database.NewDatabaseAdminClient(ctx)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to create database admin client: %w", err)
+	}
+	defer adminClient.Close()
+
+	existing, err := adminClient.GetDatabase(ctx, &databasepb.GetDatabaseRequest{Name: dm.config.DatabasePath()})
+	if status.Code(err) == codes.NotFound {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to look up database %s: %w", dm.config.DatabaseID, err)
+	}
+	return dialectFromDatabase(existing.GetDatabaseDialect()), true, nil
+}
+
+// ensureDatabase creates the database in the given dialect, GoogleSQL when empty, if it
+// does not exist yet, and returns the dialect of the database. An existing database must
+// have the given dialect, when one is set.
+func (dm *DatabaseManager) ensureDatabase(ctx context.Context, dialect Dialect) (Dialect, error) {
+	existing, exists, err := dm.lookupDatabase(ctx)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		if dialect != "" && dialect != existing {
+			return "", fmt.Errorf("database %s uses the %s dialect but is configured as %s; drop it or fix its dialect setting",
+				dm.config.DatabaseID, existing, dialect)
+		}
+		dm.setDialect(existing)
+		return existing, nil
+	}
+
+	if dialect == "" {
+		dialect = DialectGoogleSQL
+	}
+
+	adminClient, err := database.NewDatabaseAdminClient(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to create database admin client: %w", err)
+	}
+	defer adminClient.Close()
+
+	// PostgreSQL databases cannot take extra statements on creation, so the schema is
+	// always applied separately
+	op, err := adminClient.CreateDatabase(ctx, &databasepb.CreateDatabaseRequest{
+		Parent:          dm.config.InstancePath(),
+		CreateStatement: dialect.CreateDatabaseStatement(dm.config.DatabaseID),
+		DatabaseDialect: dialect.databaseDialect(),
+	})
+	if err == nil {
+		_, err = op.Wait(ctx)
+	}
+	if status.Code(err) == codes.AlreadyExists {
+		// Another process created it first; check its dialect like any existing database
+		return dm.ensureDatabase(ctx, dialect)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create database %s: %w", dm.config.DatabaseID, err)
+	}
+
+	log.Printf("Created %s database %s", dialect, dm.config.DatabaseID)
+	dm.setDialect(dialect)
+	return dialect, nil
+}
+
+// dropDatabase drops the database and everything in it
+func (dm *DatabaseManager) dropDatabase(ctx context.Context) error {
+	adminClient, err := database.NewDatabaseAdminClient(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create database admin client: %w", err)
+	}
+	defer adminClient.Close()
+
+	err = adminClient.DropDatabase(ctx, &databasepb.DropDatabaseRequest{Database: dm.config.DatabasePath()})
+	if err != nil && status.Code(err) != codes.NotFound {
+		return fmt.Errorf("failed to drop database %s: %w", dm.config.DatabaseID, err)
+	}
+
+	log.Printf("Dropped database %s", dm.config.DatabaseID)
+	return nil
+}
@@ -0,0 +1,104 @@
+package spanwright
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DefaultSchemaCacheFile is where the spanwright command records the schema of each database
+const DefaultSchemaCacheFile = ".spanwright/schema-cache.json"
+
+// SchemaHash returns a content hash of DDL statements as read by ReadSchemaFiles. It only
+// depends on the dialect and the statement text, so renaming or splitting schema files
+// does not change it.
+func SchemaHash(statements []DDLStatement, dialect Dialect) string {
+	h := sha256.New()
+	h.Write([]byte(dialect))
+	for _, statement := range statements {
+		h.Write([]byte("\n;\n"))
+		h.Write([]byte(strings.TrimSpace(statement.SQL)))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// SchemaCache records the schema hash each database was last built with, keyed by emulator
+// host and database path. It is safe for concurrent use.
+type SchemaCache struct {
+	path string
+
+	mu     sync.Mutex
+	hashes map[string]string
+}
+
+// LoadSchemaCache reads a schema cache file; a missing file is an empty cache
+func LoadSchemaCache(path string) (*SchemaCache, error) {
+	cache := &SchemaCache{path: path, hashes: map[string]string{}}
+
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema cache %s: %w", path, err)
+	}
+	if err := json.Unmarshal(content, &cache.hashes); err != nil {
+		return nil, fmt.Errorf("invalid schema cache %s: %w", path, err)
+	}
+	return cache, nil
+}
+
+// Lookup returns the schema hash recorded for a database, or ""
+func (c *SchemaCache) Lookup(key string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hashes[key]
+}
+
+// Record stores the schema hash of a database and writes the cache file
+func (c *SchemaCache) Record(key, hash string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.hashes[key] = hash
+	return c.save()
+}
+
+// Forget removes the entry of a database, e.g. before it is rebuilt, and writes the cache file
+func (c *SchemaCache) Forget(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.hashes[key]; !ok {
+		return nil
+	}
+	delete(c.hashes, key)
+	return c.save()
+}
+
+// save writes the cache file; the caller holds c.mu
+func (c *SchemaCache) save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", c.path, err)
+	}
+
+	content, err := json.MarshalIndent(c.hashes, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode schema cache: %w", err)
+	}
+	if err := os.WriteFile(c.path, append(content, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write schema cache %s: %w", c.path, err)
+	}
+	return nil
+}
+
+// schemaCacheKey identifies a database across emulators: the same path on another emulator
+// is a different database
+func (dc *DatabaseConfig) schemaCacheKey() string {
+	return os.Getenv("SPANNER_EMULATOR_HOST") + "/" + dc.DatabasePath()
+}
@@ -0,0 +1,122 @@
+package spanwright
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSchemaHash(t *testing.T) {
+	users := DDLStatement{File: "001_users.sql", Line: 1, SQL: "CREATE TABLE Users (UserID STRING(36)) PRIMARY KEY (UserID)"}
+	orders := DDLStatement{File: "002_orders.sql", Line: 1, SQL: "CREATE TABLE Orders (OrderID STRING(36)) PRIMARY KEY (OrderID)"}
+	base := SchemaHash([]DDLStatement{users, orders}, DialectGoogleSQL)
+
+	moved := []DDLStatement{
+		{File: "schema.sql", Line: 3, SQL: "\n" + users.SQL + "\n"},
+		{File: "schema.sql", Line: 9, SQL: orders.SQL},
+	}
+	if got := SchemaHash(moved, DialectGoogleSQL); got != base {
+		t.Errorf("SchemaHash() changed when statements moved between files: %s, want %s", got, base)
+	}
+
+	changed := []struct {
+		name       string
+		statements []DDLStatement
+		dialect    Dialect
+	}{
+		{name: "statement order", statements: []DDLStatement{orders, users}, dialect: DialectGoogleSQL},
+		{name: "statement text", statements: []DDLStatement{users, {SQL: orders.SQL + ", ROW DELETION POLICY (OLDER_THAN(CreatedAt, INTERVAL 1 DAY))"}}, dialect: DialectGoogleSQL},
+		{name: "statement boundary", statements: []DDLStatement{{SQL: users.SQL + orders.SQL}}, dialect: DialectGoogleSQL},
+		{name: "dialect", statements: []DDLStatement{users, orders}, dialect: DialectPostgreSQL},
+	}
+	for _, tt := range changed {
+		if got := SchemaHash(tt.statements, tt.dialect); got == base {
+			t.Errorf("SchemaHash() did not change with the %s", tt.name)
+		}
+	}
+}
+
+func TestSchemaCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".spanwright", "schema-cache.json")
+
+	cache, err := LoadSchemaCache(path)
+	if err != nil {
+		t.Fatalf("LoadSchemaCache() of a missing file error = %v", err)
+	}
+	if got := cache.Lookup("localhost:9010/projects/p/instances/i/databases/primary-db"); got != "" {
+		t.Errorf("Lookup() on an empty cache = %q", got)
+	}
+
+	if err := cache.Record("localhost:9010/projects/p/instances/i/databases/primary-db", "abc"); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if err := cache.Record("localhost:9010/projects/p/instances/i/databases/secondary-db", "def"); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if err := cache.Forget("localhost:9010/projects/p/instances/i/databases/secondary-db"); err != nil {
+		t.Fatalf("Forget() error = %v", err)
+	}
+
+	reloaded, err := LoadSchemaCache(path)
+	if err != nil {
+		t.Fatalf("LoadSchemaCache() error = %v", err)
+	}
+	if got := reloaded.Lookup("localhost:9010/projects/p/instances/i/databases/primary-db"); got != "abc" {
+		t.Errorf("Lookup() after reload = %q, want abc", got)
+	}
+	if got := reloaded.Lookup("localhost:9010/projects/p/instances/i/databases/secondary-db"); got != "" {
+		t.Errorf("Lookup() of a forgotten database = %q, want empty", got)
+	}
+
+	if err := os.WriteFile(path, []byte("not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSchemaCache(path); err == nil {
+		t.Error("LoadSchemaCache() of an invalid file succeeded, want an error")
+	}
+}
+
+func TestRebuildReason(t *testing.T) {
+	const key = "localhost:9010/projects/p/instances/i/databases/primary-db"
+	cache, err := LoadSchemaCache(filepath.Join(t.TempDir(), "schema-cache.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		cache    *SchemaCache
+		recorded string
+		existing Dialect
+		want     string
+	}{
+		{name: "no cache", want: "no schema cache is used"},
+		{name: "missing entry", cache: cache, want: "has no entry for it"},
+		{name: "changed schema", cache: cache, recorded: "old", want: "its schema changed"},
+		{name: "changed dialect", cache: cache, recorded: "abc", existing: DialectPostgreSQL, want: "its dialect is POSTGRESQL"},
+		{name: "unchanged", cache: cache, recorded: "abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.recorded != "" {
+				if err := cache.Record(key, tt.recorded); err != nil {
+					t.Fatal(err)
+				}
+			}
+			existing := tt.existing
+			if existing == "" {
+				existing = DialectGoogleSQL
+			}
+
+			got := rebuildReason(tt.cache, key, "abc", existing, DialectGoogleSQL)
+			if tt.want == "" && got != "" {
+				t.Errorf("rebuildReason() = %q, want no reason", got)
+			}
+			if tt.want != "" && !strings.Contains(got, tt.want) {
+				t.Errorf("rebuildReason() = %q, want one containing %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package spanwright
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// Spanner resource naming rules
+var (
+	// Project IDs: 6-30 characters, lowercase letters, digits and hyphens, no trailing hyphen
+	projectIDRegex = regexp.MustCompile(`^[a-z][a-z0-9-]{4,28}[a-z0-9]$`)
+
+	// Instance IDs: 2-64 characters, lowercase letters, digits and hyphens, no trailing hyphen
+	instanceIDRegex = regexp.MustCompile(`^[a-z][a-z0-9-]{0,62}[a-z0-9]$`)
+
+	// Database IDs: 2-30 characters, lowercase letters, digits, underscores and hyphens
+	databaseIDRegex = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,28}[a-z0-9]$`)
+
+	// Table names: start with a letter, then letters, digits, underscores and hyphens
+	tableNameRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`)
+)
+
+// allowedEnvironments lists the environments the framework may run against
+var allowedEnvironments = []string{"development", "test", "staging"}
+
+// productionPatterns mirrors the production-like name check in tests/global-setup.ts
+var productionPatterns = []string{"prod", "production", "live", "main", "master", "real", "actual", "staging", "stage"}
+
+// sqlKeywords are rejected as table name segments to catch injection attempts
+var sqlKeywords = map[string]bool{
+	"select": true,
+	"drop":   true,
+	"delete": true,
+	"update": true,
+	"insert": true,
+	"union":  true,
+	"exec":   true,
+	"script": true,
+}
+
+// ValidateProjectID validates a GCP project ID
+func ValidateProjectID(projectID string) error {
+	if projectID == "" {
+		return fmt.Errorf("project ID cannot be empty")
+	}
+
+	if len(projectID) < 6 || len(projectID) > 30 {
+		return fmt.Errorf("project ID must be between 6 and 30 characters")
+	}
+
+	if !projectIDRegex.MatchString(projectID) {
+		return fmt.Errorf("project ID must start with a lowercase letter, contain only lowercase letters, digits, and hyphens, and not end with a hyphen")
+	}
+
+	return nil
+}
+
+// ValidateInstanceID validates a Spanner instance ID
+func ValidateInstanceID(instanceID string) error {
+	if instanceID == "" {
+		return fmt.Errorf("instance ID cannot be empty")
+	}
+
+	if len(instanceID) < 2 || len(instanceID) > 64 {
+		return fmt.Errorf("instance ID must be between 2 and 64 characters")
+	}
+
+	if !instanceIDRegex.MatchString(instanceID) {
+		return fmt.Errorf("instance ID must start with a lowercase letter, contain only lowercase letters, digits, and hyphens, and not end with a hyphen")
+	}
+
+	return nil
+}
+
+// ValidateDatabaseID validates a Spanner database ID
+func ValidateDatabaseID(databaseID string) error {
+	if databaseID == "" {
+		return fmt.Errorf("database ID cannot be empty")
+	}
+
+	if len(databaseID) < 2 || len(databaseID) > 30 {
+		return fmt.Errorf("database ID must be between 2 and 30 characters")
+	}
+
+	if !databaseIDRegex.MatchString(databaseID) {
+		return fmt.Errorf("database ID must start with a lowercase letter, contain only lowercase letters, digits, underscores, and hyphens, and end with a letter or digit")
+	}
+
+	return nil
+}
+
+// ValidateTableName validates basic table name format
+func ValidateTableName(tableName string) error {
+	if tableName == "" {
+		return fmt.Errorf("table name cannot be empty")
+	}
+
+	if len(tableName) > 128 {
+		return fmt.Errorf("table name exceeds maximum length of 128 characters")
+	}
+
+	if !tableNameRegex.MatchString(tableName) {
+		return fmt.Errorf("table name must start with letter and contain only letters, digits, underscores, and hyphens")
+	}
+
+	for _, segment := range strings.FieldsFunc(strings.ToLower(tableName), func(r rune) bool {
+		return r == '_' || r == '-'
+	}) {
+		if sqlKeywords[segment] {
+			return fmt.Errorf("table name must not contain the SQL keyword %q", segment)
+		}
+	}
+
+	return nil
+}
+
+// BuildSecureDSN constructs a database path after validating every identifier
+func BuildSecureDSN(projectID, instanceID, databaseID string) (string, error) {
+	if err := ValidateSpannerIDs(projectID, instanceID, databaseID); err != nil {
+		return "", fmt.Errorf("DSN validation failed: %w", err)
+	}
+
+	return "projects/" + projectID + "/instances/" + instanceID + "/databases/" + databaseID, nil
+}
+
+// validateEnvironment ensures the environment is one the framework may run against
+func validateEnvironment(environment string) error {
+	if environment == "" {
+		return fmt.Errorf("environment cannot be empty")
+	}
+
+	normalized := strings.ToLower(environment)
+	for _, allowed := range allowedEnvironments {
+		if normalized == allowed {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("environment %q is not allowed, must be one of: %s", environment, strings.Join(allowedEnvironments, ", "))
+}
+
+// validateEmulatorHost ensures the emulator host is a loopback host:port pair
+func validateEmulatorHost(emulatorHost string) error {
+	if emulatorHost == "" {
+		return fmt.Errorf("emulator host cannot be empty")
+	}
+
+	host, err := splitEmulatorHost(emulatorHost)
+	if err != nil {
+		return err
+	}
+
+	if !isLoopbackHost(host) {
+		return fmt.Errorf("emulator host %q must be a loopback address", host)
+	}
+
+	return nil
+}
+
+// isLoopbackHost reports whether a host name or IP literal refers to the local machine
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// validateNonProductionID rejects identifiers that look like production resources
+func validateNonProductionID(id, fieldName string) error {
+	lower := strings.ToLower(id)
+	for _, pattern := range productionPatterns {
+		if strings.Contains(lower, pattern) {
+			return fmt.Errorf("%s %q appears production-like (contains %q) - only test/dev allowed", fieldName, id, pattern)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package spanwright
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"cloud.google.com/go/spanner"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultSeededDir is where the spanwright tools record the keys of the rows they seeded
+const DefaultSeededDir = ".spanwright/seeded"
+
+// SeededRows records the primary keys of the rows seeded into a database, so Reset with
+// ResetSeeded can delete exactly those rows
+type SeededRows struct {
+	DatabaseID string                  `yaml:"database_id"`
+	Tables     map[string]*SeededTable `yaml:"tables"`
+}
+
+// SeededTable holds the primary keys of the rows seeded into one table, each in Key order.
+// Untracked is set when a fixture row leaves part of its key to a column default, so the
+// keys of the inserted rows are not known.
+type SeededTable struct {
+	Key       []string        `yaml:"key"`
+	Rows      [][]interface{} `yaml:"rows,omitempty"`
+	Untracked bool            `yaml:"untracked,omitempty"`
+}
+
+// seededTable collects the primary key values of the fixture rows, as written in the fixture
+func seededTable(fixture *Fixture, table *Table) *SeededTable {
+	seeded := &SeededTable{}
+	for _, key := range table.PrimaryKey {
+		seeded.Key = append(seeded.Key, key.Name)
+	}
+
+	for _, row := range fixture.Rows {
+		values := make([]interface{}, 0, len(seeded.Key))
+		for _, column := range seeded.Key {
+			value, ok := row[column]
+			if !ok {
+				return &SeededTable{Key: seeded.Key, Untracked: true}
+			}
+			values = append(values, value)
+		}
+		seeded.Rows = append(seeded.Rows, values)
+	}
+	return seeded
+}
+
+// seededDeletes returns mutations deleting the seeded rows, children first following the
+// parents-first table order. Untracked tables and keys that no longer match the schema are
+// errors, so nothing is deleted unless every seeded row can be.
+func seededDeletes(seeded *SeededRows, schema *DatabaseSchema, order []string) ([]*spanner.Mutation, error) {
+	var untracked []string
+	for name, table := range seeded.Tables {
+		if table.Untracked {
+			untracked = append(untracked, name)
+		}
+	}
+	if len(untracked) > 0 {
+		sort.Strings(untracked)
+		return nil, fmt.Errorf("the seeded rows of %v are not tracked because their fixtures omit part of the primary key; reset with mode %s instead",
+			untracked, ResetAll)
+	}
+
+	for name := range seeded.Tables {
+		if schema.Table(name) == nil {
+			return nil, fmt.Errorf("seeded table %s does not exist", name)
+		}
+	}
+
+	var deletes []*spanner.Mutation
+	for i := len(order) - 1; i >= 0; i-- {
+		seededTable, ok := seeded.Tables[order[i]]
+		if !ok {
+			continue
+		}
+		table := schema.Table(order[i])
+
+		var key []string
+		for _, column := range table.PrimaryKey {
+			key = append(key, column.Name)
+		}
+		if !slices.Equal(key, seededTable.Key) {
+			return nil, fmt.Errorf("table %s: seeded rows are keyed by %v but the primary key is %v", order[i], seededTable.Key, key)
+		}
+
+		for r, row := range seededTable.Rows {
+			if len(row) != len(key) {
+				return nil, fmt.Errorf("table %s: seeded row %d has %d key values, want %d", order[i], r+1, len(row), len(key))
+			}
+			values := make(spanner.Key, 0, len(key))
+			for c, column := range key {
+				value, err := convertValue(row[c], table.Column(column).Type, schema.Dialect)
+				if err != nil {
+					return nil, fmt.Errorf("table %s: seeded row %d column %s: %w", order[i], r+1, column, err)
+				}
+				values = append(values, value)
+			}
+			deletes = append(deletes, spanner.Delete(order[i], values))
+		}
+	}
+	return deletes, nil
+}
+
+// SeededRows returns the rows recorded as seeded into the database, or nil when none are
+func (dm *DatabaseManager) SeededRows() *SeededRows {
+	dm.seededMu.Lock()
+	defer dm.seededMu.Unlock()
+	return dm.seeded
+}
+
+// recordSeeded replaces the seeded rows recorded for one table
+func (dm *DatabaseManager) recordSeeded(name string, table *SeededTable) {
+	dm.seededMu.Lock()
+	defer dm.seededMu.Unlock()
+
+	if dm.seeded == nil {
+		dm.seeded = &SeededRows{DatabaseID: dm.config.DatabaseID, Tables: map[string]*SeededTable{}}
+	}
+	dm.seeded.Tables[name] = table
+}
+
+// clearSeeded forgets the seeded rows, once they have been deleted
+func (dm *DatabaseManager) clearSeeded() {
+	dm.seededMu.Lock()
+	defer dm.seededMu.Unlock()
+	dm.seeded = nil
+}
+
+// LoadSeededRows reads the seeded rows recorded by an earlier run from a file written by
+// SaveSeededRows; a missing file means nothing was seeded
+func (dm *DatabaseManager) LoadSeededRows(path string) error {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		dm.clearSeeded()
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read seeded rows %s: %w", path, err)
+	}
+
+	var seeded SeededRows
+	if err := yaml.Unmarshal(content, &seeded); err != nil {
+		return fmt.Errorf("invalid seeded rows file %s: %w", path, err)
+	}
+	if seeded.DatabaseID != dm.config.DatabaseID {
+		return fmt.Errorf("seeded rows file %s is for database %s, not %s", path, seeded.DatabaseID, dm.config.DatabaseID)
+	}
+	if seeded.Tables == nil {
+		seeded.Tables = map[string]*SeededTable{}
+	}
+
+	dm.seededMu.Lock()
+	defer dm.seededMu.Unlock()
+	dm.seeded = &seeded
+	return nil
+}
+
+// SaveSeededRows writes the seeded rows to a file, creating the directory if needed, or
+// removes the file when no rows are recorded
+func (dm *DatabaseManager) SaveSeededRows(path string) error {
+	seeded := dm.SeededRows()
+	if seeded == nil {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove seeded rows %s: %w", path, err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	content, err := yaml.Marshal(seeded)
+	if err != nil {
+		return fmt.Errorf("failed to encode seeded rows: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write seeded rows %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package spanwright
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+)
+
+func seededTestSchema() *DatabaseSchema {
+	return &DatabaseSchema{
+		Dialect: DialectGoogleSQL,
+		Tables: []*Table{
+			{Name: "Users", Columns: []*Column{
+				{Name: "UserID", Type: "STRING(36)"},
+				{Name: "Name", Type: "STRING(MAX)", Nullable: true},
+			}, PrimaryKey: []KeyColumn{{Name: "UserID"}}},
+			{Name: "Posts", Columns: []*Column{
+				{Name: "UserID", Type: "STRING(36)"},
+				{Name: "PostID", Type: "INT64"},
+			}, PrimaryKey: []KeyColumn{{Name: "UserID"}, {Name: "PostID"}}, Parent: "Users", OnDelete: "NO ACTION"},
+			{Name: "Tags", Columns: []*Column{
+				{Name: "TagID", Type: "INT64"},
+			}, PrimaryKey: []KeyColumn{{Name: "TagID"}}},
+		},
+	}
+}
+
+func TestSeededTable(t *testing.T) {
+	schema := seededTestSchema()
+
+	fixture := &Fixture{Table: "Posts", Rows: []map[string]interface{}{
+		{"UserID": "user-001", "PostID": 1, "Title": "Hello"},
+		{"UserID": "user-001", "PostID": 2},
+	}}
+	want := &SeededTable{Key: []string{"UserID", "PostID"}, Rows: [][]interface{}{{"user-001", 1}, {"user-001", 2}}}
+	if got := seededTable(fixture, schema.Table("Posts")); !reflect.DeepEqual(got, want) {
+		t.Errorf("seededTable() = %+v, want %+v", got, want)
+	}
+
+	// A key left to a column default cannot be tracked
+	fixture = &Fixture{Table: "Tags", Rows: []map[string]interface{}{{"TagID": 1}, {}}}
+	want = &SeededTable{Key: []string{"TagID"}, Untracked: true}
+	if got := seededTable(fixture, schema.Table("Tags")); !reflect.DeepEqual(got, want) {
+		t.Errorf("seededTable() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSeededDeletes(t *testing.T) {
+	schema := seededTestSchema()
+	seeded := &SeededRows{Tables: map[string]*SeededTable{
+		"Users": {Key: []string{"UserID"}, Rows: [][]interface{}{{"user-001"}}},
+		"Posts": {Key: []string{"UserID", "PostID"}, Rows: [][]interface{}{{"user-001", 1}, {"user-001", "2"}}},
+	}}
+
+	deletes, err := seededDeletes(seeded, schema, []string{"Users", "Posts", "Tags"})
+	if err != nil {
+		t.Fatalf("seededDeletes() error = %v", err)
+	}
+
+	// Interleaved rows go before their parents; keys take the column types
+	want := []*spanner.Mutation{
+		spanner.Delete("Posts", spanner.Key{"user-001", int64(1)}),
+		spanner.Delete("Posts", spanner.Key{"user-001", int64(2)}),
+		spanner.Delete("Users", spanner.Key{"user-001"}),
+	}
+	if !reflect.DeepEqual(deletes, want) {
+		t.Errorf("seededDeletes() = %v, want %v", deletes, want)
+	}
+}
+
+func TestSeededDeletesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		tables map[string]*SeededTable
+		want   string
+	}{
+		{
+			name:   "untracked",
+			tables: map[string]*SeededTable{"Tags": {Key: []string{"TagID"}, Untracked: true}},
+			want:   "the seeded rows of [Tags] are not tracked",
+		},
+		{
+			name:   "unknown table",
+			tables: map[string]*SeededTable{"Carts": {Key: []string{"CartID"}}},
+			want:   "seeded table Carts does not exist",
+		},
+		{
+			name:   "changed key",
+			tables: map[string]*SeededTable{"Posts": {Key: []string{"PostID"}, Rows: [][]interface{}{{1}}}},
+			want:   "table Posts: seeded rows are keyed by [PostID] but the primary key is [UserID PostID]",
+		},
+		{
+			name:   "short row",
+			tables: map[string]*SeededTable{"Posts": {Key: []string{"UserID", "PostID"}, Rows: [][]interface{}{{"user-001"}}}},
+			want:   "table Posts: seeded row 1 has 1 key values, want 2",
+		},
+		{
+			name:   "bad value",
+			tables: map[string]*SeededTable{"Tags": {Key: []string{"TagID"}, Rows: [][]interface{}{{"first"}}}},
+			want:   "table Tags: seeded row 1 column TagID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := seededDeletes(&SeededRows{Tables: tt.tables}, seededTestSchema(), []string{"Users", "Posts", "Tags"})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("seededDeletes() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeededRowsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".spanwright", "seeded", "primary-db.yaml")
+	dm := &DatabaseManager{config: &DatabaseConfig{DatabaseID: "primary-db"}}
+
+	if err := dm.LoadSeededRows(path); err != nil {
+		t.Fatalf("LoadSeededRows() of a missing file error = %v", err)
+	}
+	if got := dm.SeededRows(); got != nil {
+		t.Errorf("SeededRows() after loading a missing file = %+v, want nil", got)
+	}
+
+	dm.recordSeeded("Posts", &SeededTable{Key: []string{"UserID", "PostID"}, Rows: [][]interface{}{{"user-001", 1}}})
+	if err := dm.SaveSeededRows(path); err != nil {
+		t.Fatalf("SaveSeededRows() error = %v", err)
+	}
+
+	reloaded := &DatabaseManager{config: &DatabaseConfig{DatabaseID: "primary-db"}}
+	if err := reloaded.LoadSeededRows(path); err != nil {
+		t.Fatalf("LoadSeededRows() error = %v", err)
+	}
+	if got, want := reloaded.SeededRows(), dm.SeededRows(); !reflect.DeepEqual(got, want) {
+		t.Errorf("SeededRows() after reload = %+v, want %+v", got, want)
+	}
+
+	other := &DatabaseManager{config: &DatabaseConfig{DatabaseID: "secondary-db"}}
+	if err := other.LoadSeededRows(path); err == nil {
+		t.Error("LoadSeededRows() of another database's file succeeded, want an error")
+	}
+
+	// Once the rows are reset the file goes away
+	reloaded.clearSeeded()
+	if err := reloaded.SaveSeededRows(path); err != nil {
+		t.Fatalf("SaveSeededRows() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("seeded rows file still exists after saving no rows: %v", err)
+	}
+}
+
+func TestParseResetMode(t *testing.T) {
+	for _, value := range []string{"all", "seeded", "recreate"} {
+		if mode, err := ParseResetMode(value); err != nil || string(mode) != value {
+			t.Errorf("ParseResetMode(%q) = %q, %v", value, mode, err)
+		}
+	}
+	if _, err := ParseResetMode("truncate"); err == nil {
+		t.Error("ParseResetMode(truncate) succeeded, want an error")
+	}
+}
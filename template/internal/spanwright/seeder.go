@@ -0,0 +1,715 @@
+package spanwright
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+	"gopkg.in/yaml.v3"
+)
+
+// seedBatchSize is the number of rows written per ApplyMutations call
+const seedBatchSize = 500
+
+// Fixture represents the rows of a single YAML fixture file
+type Fixture struct {
+	Table string
+	File  string
+	Rows  []map[string]interface{}
+}
+
+// LoadFixtureFile reads a YAML fixture file; the table name is taken from the file name,
+// which for tables in a named schema is <schema>.<table>.yaml
+func LoadFixtureFile(path string) (*Fixture, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture file %s: %w", path, err)
+	}
+
+	var rows []map[string]interface{}
+	if err := yaml.Unmarshal(content, &rows); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture file %s: %w", path, err)
+	}
+
+	base := filepath.Base(path)
+	table := strings.TrimSuffix(base, filepath.Ext(base))
+	parts := strings.Split(table, ".")
+	if len(parts) > 2 {
+		return nil, fmt.Errorf("invalid fixture file name %s: expected <table>.yaml or <schema>.<table>.yaml", path)
+	}
+	for _, part := range parts {
+		if err := ValidateTableName(part); err != nil {
+			return nil, fmt.Errorf("invalid fixture file name %s: %w", path, err)
+		}
+	}
+
+	return &Fixture{Table: table, File: path, Rows: rows}, nil
+}
+
+// FixtureFiles returns the *.yml and *.yaml files of a fixture directory; SeedFiles
+// orders them by table dependencies
+func FixtureFiles(fixtureDir string) ([]string, error) {
+	if _, err := os.Stat(fixtureDir); err != nil {
+		return nil, fmt.Errorf("fixture directory not accessible: %w", err)
+	}
+
+	var files []string
+	for _, pattern := range []string{"*.yml", "*.yaml"} {
+		matches, err := filepath.Glob(filepath.Join(fixtureDir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files: %w", err)
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// Seeder loads YAML fixtures into a database through DatabaseManager mutations
+type Seeder struct {
+	dm *DatabaseManager
+}
+
+// NewSeeder creates a new Seeder
+func NewSeeder(dm *DatabaseManager) *Seeder {
+	return &Seeder{dm: dm}
+}
+
+// SeedFiles replaces the contents of each fixture table with the rows of its fixture file.
+// Tables are filled parents first and cleared children first, following interleave and
+// foreign key relationships. The keys of the seeded rows are recorded on the manager; see
+// SeededRows.
+func (s *Seeder) SeedFiles(ctx context.Context, files []string) error {
+	ctx, cancel := s.dm.operationContext(ctx)
+	defer cancel()
+
+	fixtures := make(map[string]*Fixture, len(files))
+	tables := make([]string, 0, len(files))
+	for _, file := range files {
+		fixture, err := LoadFixtureFile(file)
+		if err != nil {
+			return err
+		}
+		if existing, ok := fixtures[fixture.Table]; ok {
+			return fmt.Errorf("table %s has more than one fixture file: %s and %s", fixture.Table, existing.File, fixture.File)
+		}
+		fixtures[fixture.Table] = fixture
+		tables = append(tables, fixture.Table)
+	}
+
+	schema, err := s.dm.DescribeSchema(ctx)
+	if err != nil {
+		return err
+	}
+	order, err := SortTablesByDependency(tables, schema.Dependencies())
+	if err != nil {
+		return fmt.Errorf("failed to order fixture tables: %w", err)
+	}
+
+	var inserts []tableInserts
+	for _, table := range order {
+		fixture := fixtures[table]
+		info := schema.Table(table)
+		if info == nil {
+			return fmt.Errorf("fixture %s: table %s does not exist", fixture.File, table)
+		}
+
+		mutations, err := buildInsertMutations(fixture, info, schema.Dialect)
+		if err != nil {
+			return err
+		}
+		inserts = append(inserts, tableInserts{table: table, source: "fixture " + fixture.File, inserts: mutations})
+	}
+
+	// Clear existing rows children first so interleaved and referencing rows go before their parents
+	if err := s.dm.ApplyMutations(ctx, clearMutations(order)); err != nil {
+		return fmt.Errorf("failed to clear fixture tables: %w", err)
+	}
+
+	if err := s.dm.insertRows(ctx, inserts); err != nil {
+		return fmt.Errorf("failed to insert fixture rows: %w", err)
+	}
+
+	for _, table := range order {
+		s.dm.recordSeeded(table, seededTable(fixtures[table], schema.Table(table)))
+		log.Printf("📄 Seeded %d rows into %s", len(fixtures[table].Rows), table)
+	}
+	return nil
+}
+
+// tableInserts holds the insert mutations of one table's rows, in row order
+type tableInserts struct {
+	table string
+	// source names where the rows come from in errors, such as the fixture file
+	source  string
+	inserts []*spanner.Mutation
+}
+
+// applyInBatches applies mutations seedBatchSize at a time, keeping each commit under
+// Spanner's mutation limit. Unlike ApplyMutations, rows that already exist are errors.
+func (dm *DatabaseManager) applyInBatches(ctx context.Context, mutations []*spanner.Mutation) error {
+	_, err := dm.applyBatches(ctx, mutations)
+	return err
+}
+
+// applyBatches is applyInBatches that also returns the index of the first mutation of the
+// failed batch
+func (dm *DatabaseManager) applyBatches(ctx context.Context, mutations []*spanner.Mutation) (int, error) {
+	for start := 0; start < len(mutations); start += seedBatchSize {
+		end := min(start+seedBatchSize, len(mutations))
+		if err := dm.applyMutations(ctx, mutations[start:end]); err != nil {
+			return start, err
+		}
+	}
+	return 0, nil
+}
+
+// insertRows writes the rows table by table in the given order, so a failed commit can be
+// traced to the table and rows it held
+func (dm *DatabaseManager) insertRows(ctx context.Context, tables []tableInserts) error {
+	for _, table := range tables {
+		start, err := dm.applyBatches(ctx, table.inserts)
+		if err != nil {
+			end := min(start+seedBatchSize, len(table.inserts))
+			return fmt.Errorf("%s: table %s rows %d-%d: %w", table.source, table.table, start+1, end, err)
+		}
+	}
+	return nil
+}
+
+// buildInsertMutations converts fixture rows into insert mutations using the table's column
+// types. Rows with the same primary key are rejected before anything is written.
+func buildInsertMutations(fixture *Fixture, table *Table, dialect Dialect) ([]*spanner.Mutation, error) {
+	mutations := make([]*spanner.Mutation, 0, len(fixture.Rows))
+	keys := make(map[string]int, len(fixture.Rows))
+	for i, row := range fixture.Rows {
+		key, ok, err := fixtureRowKey(row, table, dialect)
+		if err != nil {
+			return nil, fmt.Errorf("fixture %s row %d: %w", fixture.File, i+1, err)
+		}
+		if ok {
+			if first, seen := keys[key]; seen {
+				return nil, fmt.Errorf("fixture %s row %d: table %s already has a row with primary key %s in row %d",
+					fixture.File, i+1, fixture.Table, key, first)
+			}
+			keys[key] = i + 1
+		}
+
+		columns := make([]string, 0, len(row))
+		for column := range row {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+
+		values := make([]interface{}, 0, len(columns))
+		for _, column := range columns {
+			info := table.Column(column)
+			if info == nil {
+				return nil, fmt.Errorf("fixture %s row %d: column %s does not exist in table %s", fixture.File, i+1, column, fixture.Table)
+			}
+			if info.Generated {
+				return nil, fmt.Errorf("fixture %s row %d: column %s of table %s is generated and cannot be written", fixture.File, i+1, column, fixture.Table)
+			}
+			value, err := convertValue(row[column], info.Type, dialect)
+			if err != nil {
+				return nil, fmt.Errorf("fixture %s row %d column %s: %w", fixture.File, i+1, column, err)
+			}
+			values = append(values, value)
+		}
+
+		mutations = append(mutations, spanner.Insert(fixture.Table, columns, values))
+	}
+	return mutations, nil
+}
+
+// fixtureRowKey returns the primary key of a fixture row as comparable text, so 1 and "1"
+// in an INT64 key are the same key. ok is false when the row leaves part of its key to a
+// column default.
+func fixtureRowKey(row map[string]interface{}, table *Table, dialect Dialect) (string, bool, error) {
+	parts := make([]string, 0, len(table.PrimaryKey))
+	for _, key := range table.PrimaryKey {
+		value, ok := row[key.Name]
+		if !ok {
+			return "", false, nil
+		}
+		if value == nil {
+			parts = append(parts, "NULL")
+			continue
+		}
+
+		info := table.Column(key.Name)
+		if info == nil {
+			return "", false, fmt.Errorf("key column %s does not exist in table %s", key.Name, table.Name)
+		}
+		ct, err := parseColumnType(info.Type, dialect)
+		if err != nil {
+			return "", false, fmt.Errorf("column %s: %w", key.Name, err)
+		}
+		text, err := canonicalValue(value, ct.kind)
+		if err != nil {
+			return "", false, fmt.Errorf("column %s: %w", key.Name, err)
+		}
+		parts = append(parts, strconv.Quote(text))
+	}
+	return "(" + strings.Join(parts, ", ") + ")", true, nil
+}
+
+// GetColumnTypes returns the Spanner type of each column in a table, keyed by column name.
+// Tables in named schemas are addressed by their qualified name.
+func (dm *DatabaseManager) GetColumnTypes(ctx context.Context, tableName string) (map[string]string, error) {
+	schema, err := dm.DescribeSchema(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading columns of table %s: %w", tableName, err)
+	}
+
+	table := schema.Table(tableName)
+	if table == nil {
+		return map[string]string{}, nil
+	}
+	return table.ColumnTypes(), nil
+}
+
+// columnKind identifies the scalar type of a column
+type columnKind int
+
+const (
+	kindString columnKind = iota
+	kindInt64
+	kindFloat64
+	kindFloat32
+	kindBool
+	kindBytes
+	kindTimestamp
+	kindDate
+	kindNumeric
+	kindJSON
+	kindPGNumeric
+	kindPGJsonB
+)
+
+// columnType is a parsed INFORMATION_SCHEMA spanner_type
+type columnType struct {
+	kind  columnKind
+	array bool
+}
+
+// parseColumnType parses a spanner_type such as STRING(MAX) or ARRAY<INT64>, or for
+// PostgreSQL databases character varying(36) or bigint[]
+func parseColumnType(spannerType string, dialect Dialect) (columnType, error) {
+	if dialect.IsPostgreSQL() {
+		return parsePGColumnType(spannerType)
+	}
+
+	t := strings.ToUpper(strings.TrimSpace(spannerType))
+
+	var ct columnType
+	if strings.HasPrefix(t, "ARRAY<") && strings.HasSuffix(t, ">") {
+		ct.array = true
+		t = strings.TrimSuffix(strings.TrimPrefix(t, "ARRAY<"), ">")
+	}
+	if i := strings.IndexByte(t, '('); i >= 0 {
+		t = t[:i]
+	}
+
+	switch t {
+	case "STRING":
+		ct.kind = kindString
+	case "INT64":
+		ct.kind = kindInt64
+	case "FLOAT64":
+		ct.kind = kindFloat64
+	case "FLOAT32":
+		ct.kind = kindFloat32
+	case "BOOL":
+		ct.kind = kindBool
+	case "BYTES":
+		ct.kind = kindBytes
+	case "TIMESTAMP":
+		ct.kind = kindTimestamp
+	case "DATE":
+		ct.kind = kindDate
+	case "NUMERIC":
+		ct.kind = kindNumeric
+	case "JSON":
+		ct.kind = kindJSON
+	default:
+		return columnType{}, fmt.Errorf("unsupported column type %s", spannerType)
+	}
+	return ct, nil
+}
+
+// parsePGColumnType parses the spanner_type of a PostgreSQL-dialect column
+func parsePGColumnType(spannerType string) (columnType, error) {
+	t := strings.ToLower(strings.TrimSpace(spannerType))
+
+	var ct columnType
+	if strings.HasSuffix(t, "[]") {
+		ct.array = true
+		t = strings.TrimSuffix(t, "[]")
+	}
+	if i := strings.IndexByte(t, '('); i >= 0 {
+		t = strings.TrimSpace(t[:i])
+	}
+
+	switch t {
+	case "character varying", "varchar", "text":
+		ct.kind = kindString
+	case "bigint", "int8":
+		ct.kind = kindInt64
+	case "double precision", "float8":
+		ct.kind = kindFloat64
+	case "real", "float4":
+		ct.kind = kindFloat32
+	case "boolean", "bool":
+		ct.kind = kindBool
+	case "bytea":
+		ct.kind = kindBytes
+	case "timestamp with time zone", "timestamptz", "spanner.commit_timestamp":
+		ct.kind = kindTimestamp
+	case "date":
+		ct.kind = kindDate
+	case "numeric":
+		ct.kind = kindPGNumeric
+	case "jsonb":
+		ct.kind = kindPGJsonB
+	default:
+		return columnType{}, fmt.Errorf("unsupported column type %s", spannerType)
+	}
+	return ct, nil
+}
+
+// convertValue converts a YAML-decoded value into a Spanner value of the given spanner_type
+func convertValue(value interface{}, spannerType string, dialect Dialect) (interface{}, error) {
+	ct, err := parseColumnType(spannerType, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ct.array {
+		return convertScalar(value, ct.kind)
+	}
+	if value == nil {
+		return convertArray(nil, ct.kind)
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a list for %s, got %T", spannerType, value)
+	}
+	return convertArray(items, ct.kind)
+}
+
+// convertScalar converts a single value; nil becomes a typed NULL
+func convertScalar(value interface{}, kind columnKind) (interface{}, error) {
+	switch kind {
+	case kindString:
+		if value == nil {
+			return spanner.NullString{}, nil
+		}
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("cannot convert %T to STRING", value)
+		case time.Time:
+			return v.Format(time.RFC3339Nano), nil
+		default:
+			return fmt.Sprint(v), nil
+		}
+	case kindInt64:
+		if value == nil {
+			return spanner.NullInt64{}, nil
+		}
+		return toInt64(value)
+	case kindFloat64:
+		if value == nil {
+			return spanner.NullFloat64{}, nil
+		}
+		return toFloat64(value)
+	case kindFloat32:
+		if value == nil {
+			return spanner.NullFloat32{}, nil
+		}
+		f, err := toFloat64(value)
+		if err != nil {
+			return nil, err
+		}
+		return float32(f), nil
+	case kindBool:
+		if value == nil {
+			return spanner.NullBool{}, nil
+		}
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid BOOL value %q", v)
+			}
+			return b, nil
+		}
+		return nil, fmt.Errorf("cannot convert %T to BOOL", value)
+	case kindBytes:
+		if value == nil {
+			return []byte(nil), nil
+		}
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("BYTES values must be base64 strings, got %T", value)
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 BYTES value: %w", err)
+		}
+		return b, nil
+	case kindTimestamp:
+		if value == nil {
+			return spanner.NullTime{}, nil
+		}
+		return toTimestamp(value)
+	case kindDate:
+		if value == nil {
+			return spanner.NullDate{}, nil
+		}
+		switch v := value.(type) {
+		case time.Time:
+			return civil.DateOf(v), nil
+		case string:
+			d, err := civil.ParseDate(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid DATE value %q", v)
+			}
+			return d, nil
+		}
+		return nil, fmt.Errorf("cannot convert %T to DATE", value)
+	case kindNumeric:
+		if value == nil {
+			return spanner.NullNumeric{}, nil
+		}
+		r, err := toRat(value)
+		if err != nil {
+			return nil, err
+		}
+		return spanner.NullNumeric{Numeric: *r, Valid: true}, nil
+	case kindJSON:
+		if value == nil {
+			return spanner.NullJSON{}, nil
+		}
+		if s, ok := value.(string); ok {
+			var decoded interface{}
+			if err := json.Unmarshal([]byte(s), &decoded); err != nil {
+				return nil, fmt.Errorf("invalid JSON value: %w", err)
+			}
+			value = decoded
+		}
+		return spanner.NullJSON{Value: value, Valid: true}, nil
+	case kindPGNumeric:
+		if value == nil {
+			return spanner.PGNumeric{}, nil
+		}
+		n, err := toNumericString(value)
+		if err != nil {
+			return nil, err
+		}
+		return spanner.PGNumeric{Numeric: n, Valid: true}, nil
+	case kindPGJsonB:
+		if value == nil {
+			return spanner.PGJsonB{}, nil
+		}
+		decoded, err := convertScalar(value, kindJSON)
+		if err != nil {
+			return nil, err
+		}
+		return spanner.PGJsonB{Value: decoded.(spanner.NullJSON).Value, Valid: true}, nil
+	}
+	return nil, fmt.Errorf("unsupported column kind %d", kind)
+}
+
+// convertArray converts a list into a typed slice whose elements may be NULL
+func convertArray(items []interface{}, kind columnKind) (interface{}, error) {
+	switch kind {
+	case kindString:
+		return convertElements(items, kind, func(v interface{}) spanner.NullString {
+			s, ok := v.(string)
+			return spanner.NullString{StringVal: s, Valid: ok}
+		})
+	case kindInt64:
+		return convertElements(items, kind, func(v interface{}) spanner.NullInt64 {
+			n, ok := v.(int64)
+			return spanner.NullInt64{Int64: n, Valid: ok}
+		})
+	case kindFloat64:
+		return convertElements(items, kind, func(v interface{}) spanner.NullFloat64 {
+			f, ok := v.(float64)
+			return spanner.NullFloat64{Float64: f, Valid: ok}
+		})
+	case kindFloat32:
+		return convertElements(items, kind, func(v interface{}) spanner.NullFloat32 {
+			f, ok := v.(float32)
+			return spanner.NullFloat32{Float32: f, Valid: ok}
+		})
+	case kindBool:
+		return convertElements(items, kind, func(v interface{}) spanner.NullBool {
+			b, ok := v.(bool)
+			return spanner.NullBool{Bool: b, Valid: ok}
+		})
+	case kindBytes:
+		return convertElements(items, kind, func(v interface{}) []byte {
+			return v.([]byte)
+		})
+	case kindTimestamp:
+		return convertElements(items, kind, func(v interface{}) spanner.NullTime {
+			t, ok := v.(time.Time)
+			return spanner.NullTime{Time: t, Valid: ok}
+		})
+	case kindDate:
+		return convertElements(items, kind, func(v interface{}) spanner.NullDate {
+			d, ok := v.(civil.Date)
+			return spanner.NullDate{Date: d, Valid: ok}
+		})
+	case kindNumeric:
+		return convertElements(items, kind, func(v interface{}) spanner.NullNumeric {
+			return v.(spanner.NullNumeric)
+		})
+	case kindJSON:
+		return convertElements(items, kind, func(v interface{}) spanner.NullJSON {
+			return v.(spanner.NullJSON)
+		})
+	case kindPGNumeric:
+		return convertElements(items, kind, func(v interface{}) spanner.PGNumeric {
+			return v.(spanner.PGNumeric)
+		})
+	case kindPGJsonB:
+		return convertElements(items, kind, func(v interface{}) spanner.PGJsonB {
+			return v.(spanner.PGJsonB)
+		})
+	}
+	return nil, fmt.Errorf("unsupported column kind %d", kind)
+}
+
+// convertElements converts each list item and wraps it into the slice element type
+func convertElements[T any](items []interface{}, kind columnKind, wrap func(interface{}) T) ([]T, error) {
+	if items == nil {
+		return nil, nil
+	}
+	out := make([]T, len(items))
+	for i, item := range items {
+		v, err := convertScalar(item, kind)
+		if err != nil {
+			return nil, fmt.Errorf("array element %d: %w", i, err)
+		}
+		out[i] = wrap(v)
+	}
+	return out, nil
+}
+
+func toInt64(value interface{}) (int64, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, fmt.Errorf("INT64 value %d out of range", v)
+		}
+		return int64(v), nil
+	case float64:
+		if v != math.Trunc(v) {
+			return 0, fmt.Errorf("INT64 value %v is not an integer", v)
+		}
+		return int64(v), nil
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid INT64 value %q", v)
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("cannot convert %T to INT64", value)
+}
+
+func toFloat64(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid FLOAT value %q", v)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("cannot convert %T to FLOAT", value)
+}
+
+func toTimestamp(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case string:
+		if strings.EqualFold(v, "PENDING_COMMIT_TIMESTAMP()") || v == "spanner.commit_timestamp()" {
+			return spanner.CommitTimestamp, nil
+		}
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid TIMESTAMP value %q, expected RFC 3339", v)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("cannot convert %T to TIMESTAMP", value)
+}
+
+// toNumericString validates a NUMERIC value and returns its decimal text, as PostgreSQL
+// numeric values are sent
+func toNumericString(value interface{}) (string, error) {
+	if _, err := toRat(value); err != nil {
+		return "", err
+	}
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case string:
+		return strings.TrimSpace(v), nil
+	}
+	return fmt.Sprint(value), nil
+}
+
+func toRat(value interface{}) (*big.Rat, error) {
+	r := new(big.Rat)
+	switch v := value.(type) {
+	case int:
+		return r.SetInt64(int64(v)), nil
+	case int64:
+		return r.SetInt64(v), nil
+	case float64:
+		// SetFloat64 returns nil for NaN and infinities, which NUMERIC cannot hold
+		if r.SetFloat64(v) == nil {
+			return nil, fmt.Errorf("invalid NUMERIC value %v", v)
+		}
+		return r, nil
+	case string:
+		if _, ok := r.SetString(v); !ok {
+			return nil, fmt.Errorf("invalid NUMERIC value %q", v)
+		}
+		return r, nil
+	}
+	return nil, fmt.Errorf("cannot convert %T to NUMERIC", value)
+}
@@ -0,0 +1,256 @@
+package spanwright
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseColumnType(t *testing.T) {
+	tests := []struct {
+		name        string
+		spannerType string
+		dialect     Dialect
+		want        columnType
+		wantErr     bool
+	}{
+		{
+			name:        "sized string",
+			spannerType: "STRING(36)",
+			want:        columnType{kind: kindString},
+		},
+		{
+			name:        "max bytes",
+			spannerType: "BYTES(MAX)",
+			want:        columnType{kind: kindBytes},
+		},
+		{
+			name:        "int64 array",
+			spannerType: "ARRAY<INT64>",
+			want:        columnType{kind: kindInt64, array: true},
+		},
+		{
+			name:        "string array",
+			spannerType: "ARRAY<STRING(MAX)>",
+			want:        columnType{kind: kindString, array: true},
+		},
+		{
+			name:        "unsupported proto",
+			spannerType: "PROTO<examples.Book>",
+			wantErr:     true,
+		},
+		{
+			name:        "postgresql varchar",
+			spannerType: "character varying(36)",
+			dialect:     DialectPostgreSQL,
+			want:        columnType{kind: kindString},
+		},
+		{
+			name:        "postgresql numeric array",
+			spannerType: "numeric[]",
+			dialect:     DialectPostgreSQL,
+			want:        columnType{kind: kindPGNumeric, array: true},
+		},
+		{
+			name:        "postgresql commit timestamp",
+			spannerType: "spanner.commit_timestamp",
+			dialect:     DialectPostgreSQL,
+			want:        columnType{kind: kindTimestamp},
+		},
+		{
+			name:        "googlesql type in a postgresql database",
+			spannerType: "INT64",
+			dialect:     DialectPostgreSQL,
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseColumnType(tt.spannerType, tt.dialect)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseColumnType() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseColumnType() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertValue(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       interface{}
+		spannerType string
+		dialect     Dialect
+		want        interface{}
+		wantErr     bool
+	}{
+		{
+			name:        "string",
+			value:       "user-001",
+			spannerType: "STRING(36)",
+			want:        "user-001",
+		},
+		{
+			name:        "integer into string column",
+			value:       123,
+			spannerType: "STRING(MAX)",
+			want:        "123",
+		},
+		{
+			name:        "int64",
+			value:       1000,
+			spannerType: "INT64",
+			want:        int64(1000),
+		},
+		{
+			name:        "int64 from string",
+			value:       "42",
+			spannerType: "INT64",
+			want:        int64(42),
+		},
+		{
+			name:        "int64 rejects fraction",
+			value:       1.5,
+			spannerType: "INT64",
+			wantErr:     true,
+		},
+		{
+			name:        "bool",
+			value:       true,
+			spannerType: "BOOL",
+			want:        true,
+		},
+		{
+			name:        "timestamp",
+			value:       "2024-01-01T00:00:00Z",
+			spannerType: "TIMESTAMP",
+			want:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "commit timestamp",
+			value:       "PENDING_COMMIT_TIMESTAMP()",
+			spannerType: "TIMESTAMP",
+			want:        spanner.CommitTimestamp,
+		},
+		{
+			name:        "invalid timestamp",
+			value:       "yesterday",
+			spannerType: "TIMESTAMP",
+			wantErr:     true,
+		},
+		{
+			name:        "null int64",
+			value:       nil,
+			spannerType: "INT64",
+			want:        spanner.NullInt64{},
+		},
+		{
+			name:        "bytes must be base64",
+			value:       "not base64!",
+			spannerType: "BYTES(MAX)",
+			wantErr:     true,
+		},
+		{
+			name:        "array expects list",
+			value:       "a",
+			spannerType: "ARRAY<STRING(MAX)>",
+			wantErr:     true,
+		},
+		{
+			name:        "postgresql numeric",
+			value:       12.5,
+			spannerType: "numeric",
+			dialect:     DialectPostgreSQL,
+			want:        spanner.PGNumeric{Numeric: "12.5", Valid: true},
+		},
+		{
+			name:        "postgresql null numeric",
+			value:       nil,
+			spannerType: "numeric",
+			dialect:     DialectPostgreSQL,
+			want:        spanner.PGNumeric{},
+		},
+		{
+			name:        "postgresql bigint",
+			value:       "7",
+			spannerType: "bigint",
+			dialect:     DialectPostgreSQL,
+			want:        int64(7),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := convertValue(tt.value, tt.spannerType, tt.dialect)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("convertValue() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("convertValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertValueArray(t *testing.T) {
+	got, err := convertValue([]interface{}{"a", nil}, "ARRAY<STRING(MAX)>", DialectGoogleSQL)
+	if err != nil {
+		t.Fatalf("convertValue() error = %v", err)
+	}
+
+	values, ok := got.([]spanner.NullString)
+	if !ok || len(values) != 2 {
+		t.Fatalf("convertValue() = %#v, want two NullString elements", got)
+	}
+	if !values[0].Valid || values[0].StringVal != "a" || values[1].Valid {
+		t.Errorf("convertValue() = %#v, want [a, NULL]", values)
+	}
+}
+
+func TestConvertValueNumericRejectsNaN(t *testing.T) {
+	var values []interface{}
+	if err := yaml.Unmarshal([]byte("[.nan, .inf, -.inf]"), &values); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, value := range values {
+		if _, err := convertValue(value, "NUMERIC", DialectGoogleSQL); err == nil {
+			t.Errorf("convertValue(%v) into NUMERIC succeeded, want an error", value)
+		}
+		if _, err := canonicalValue(value, kindNumeric); err == nil {
+			t.Errorf("canonicalValue(%v) as NUMERIC succeeded, want an error", value)
+		}
+	}
+}
+
+func TestLoadFixtureFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Users.yml")
+	content := "- UserID: \"user-001\"\n  Status: 1\n- UserID: \"user-002\"\n  Status: 2\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fixture, err := LoadFixtureFile(path)
+	if err != nil {
+		t.Fatalf("LoadFixtureFile() error = %v", err)
+	}
+	if fixture.Table != "Users" {
+		t.Errorf("LoadFixtureFile() table = %s, want Users", fixture.Table)
+	}
+	if len(fixture.Rows) != 2 || fixture.Rows[1]["UserID"] != "user-002" {
+		t.Errorf("LoadFixtureFile() rows = %v", fixture.Rows)
+	}
+}
@@ -0,0 +1,392 @@
+package spanwright
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultSnapshotDir is where the spanwright command keeps named snapshots
+const DefaultSnapshotDir = ".spanwright/snapshots"
+
+// Snapshot holds the contents of every table of a database at one point in time. It
+// carries the column types and primary keys it needs, so it can be diffed and stored
+// without the schema.
+type Snapshot struct {
+	DatabaseID string           `yaml:"database_id"`
+	Dialect    Dialect          `yaml:"dialect"`
+	TakenAt    time.Time        `yaml:"taken_at"`
+	Tables     []*TableSnapshot `yaml:"tables"`
+}
+
+// TableSnapshot holds the rows of one table, with each row's values in Columns order
+type TableSnapshot struct {
+	Table   string          `yaml:"table"`
+	Columns []string        `yaml:"columns"`
+	Types   []string        `yaml:"types"`
+	Key     []string        `yaml:"key"`
+	Rows    [][]interface{} `yaml:"rows"`
+}
+
+// Table returns the snapshot of the named table, or nil
+func (s *Snapshot) Table(name string) *TableSnapshot {
+	for _, table := range s.Tables {
+		if table.Table == name {
+			return table
+		}
+	}
+	return nil
+}
+
+// Snapshot reads every row of every table, including generated columns, from one
+// consistent read
+func (dm *DatabaseManager) Snapshot(ctx context.Context) (*Snapshot, error) {
+	ctx, cancel := dm.operationContext(ctx)
+	defer cancel()
+
+	schema, err := dm.DescribeSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dumps, err := dm.readTables(ctx, schema, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &Snapshot{DatabaseID: dm.config.DatabaseID, Dialect: schema.Dialect, TakenAt: time.Now().UTC()}
+	for _, dump := range dumps {
+		table := schema.Table(dump.Table)
+		ts := &TableSnapshot{Table: dump.Table, Columns: dump.Columns, Rows: dump.Rows}
+		for _, column := range dump.Columns {
+			ts.Types = append(ts.Types, table.Column(column).Type)
+		}
+		for _, key := range table.PrimaryKey {
+			ts.Key = append(ts.Key, key.Name)
+		}
+		snapshot.Tables = append(snapshot.Tables, ts)
+	}
+	return snapshot, nil
+}
+
+// Restore replaces the contents of every table with the rows of a snapshot, without
+// changing the schema. Tables are cleared children first and refilled parents first,
+// following interleave and foreign key relationships, and generated columns are left for
+// the database to compute. Tables the snapshot does not list end up empty. The restore is
+// not atomic: if writing rows fails, the database is left partially filled.
+func (dm *DatabaseManager) Restore(ctx context.Context, snapshot *Snapshot) error {
+	ctx, cancel := dm.operationContext(ctx)
+	defer cancel()
+
+	schema, err := dm.DescribeSchema(ctx)
+	if err != nil {
+		return err
+	}
+
+	deletes, inserts, err := restoreMutations(snapshot, schema)
+	if err != nil {
+		return err
+	}
+
+	if err := dm.ApplyMutations(ctx, deletes); err != nil {
+		return fmt.Errorf("failed to clear tables: %w", err)
+	}
+	if err := dm.insertRows(ctx, inserts); err != nil {
+		return fmt.Errorf("failed to restore rows: %w", err)
+	}
+
+	rows := 0
+	for _, table := range inserts {
+		rows += len(table.inserts)
+	}
+	log.Printf("📸 Restored %d rows into %s from the snapshot taken at %s", rows, dm.config.DatabaseID, snapshot.TakenAt.Format(time.RFC3339))
+	return nil
+}
+
+// restoreMutations returns the mutations that clear every table of the schema, children
+// first, and those that insert the snapshot rows, parents first
+func restoreMutations(snapshot *Snapshot, schema *DatabaseSchema) ([]*spanner.Mutation, []tableInserts, error) {
+	if snapshot.Dialect != "" && snapshot.Dialect != schema.Dialect {
+		return nil, nil, fmt.Errorf("snapshot of a %s database cannot be restored into a %s database", snapshot.Dialect, schema.Dialect)
+	}
+	for _, ts := range snapshot.Tables {
+		if schema.Table(ts.Table) == nil {
+			return nil, nil, fmt.Errorf("snapshot table %s does not exist", ts.Table)
+		}
+	}
+
+	order, err := tableOrder(schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	deletes := clearMutations(order)
+
+	var inserts []tableInserts
+	for _, name := range order {
+		ts := snapshot.Table(name)
+		if ts == nil {
+			continue
+		}
+		table := schema.Table(name)
+
+		var columns []string
+		var positions []int
+		var types []string
+		for i, column := range ts.Columns {
+			info := table.Column(column)
+			if info == nil {
+				return nil, nil, fmt.Errorf("snapshot column %s does not exist in table %s", column, name)
+			}
+			if info.Generated {
+				continue
+			}
+			columns = append(columns, column)
+			positions = append(positions, i)
+			types = append(types, info.Type)
+		}
+
+		rows := tableInserts{table: name, source: "snapshot"}
+		for r, row := range ts.Rows {
+			if len(row) != len(ts.Columns) {
+				return nil, nil, fmt.Errorf("snapshot table %s row %d has %d values for %d columns", name, r+1, len(row), len(ts.Columns))
+			}
+			values := make([]interface{}, len(columns))
+			for i, position := range positions {
+				value, err := convertValue(row[position], types[i], schema.Dialect)
+				if err != nil {
+					return nil, nil, fmt.Errorf("snapshot table %s row %d column %s: %w", name, r+1, columns[i], err)
+				}
+				values[i] = value
+			}
+			rows.inserts = append(rows.inserts, spanner.Insert(name, columns, values))
+		}
+		inserts = append(inserts, rows)
+	}
+	return deletes, inserts, nil
+}
+
+// WriteFile stores the snapshot as YAML, creating the directory if needed
+func (s *Snapshot) WriteFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	content, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write snapshot %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadSnapshotFile reads a snapshot written by WriteFile
+func LoadSnapshotFile(path string) (*Snapshot, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot %s: %w", path, err)
+	}
+
+	var snapshot Snapshot
+	if err := yaml.Unmarshal(content, &snapshot); err != nil {
+		return nil, fmt.Errorf("invalid snapshot %s: %w", path, err)
+	}
+	for _, table := range snapshot.Tables {
+		if len(table.Types) != len(table.Columns) {
+			return nil, fmt.Errorf("invalid snapshot %s: table %s has %d columns but %d types", path, table.Table, len(table.Columns), len(table.Types))
+		}
+	}
+	return &snapshot, nil
+}
+
+// columnTypes parses the column types of the table
+func (t *TableSnapshot) columnTypes(dialect Dialect) ([]columnType, error) {
+	types := make([]columnType, len(t.Types))
+	for i, typ := range t.Types {
+		ct, err := parseColumnType(typ, dialect)
+		if err != nil {
+			return nil, fmt.Errorf("table %s column %s: %w", t.Table, t.Columns[i], err)
+		}
+		types[i] = ct
+	}
+	return types, nil
+}
+
+// SnapshotDiff lists the rows that changed between two snapshots, by table
+type SnapshotDiff struct {
+	Tables []*TableDiff `json:"tables"`
+}
+
+// TableDiff lists the changed rows of one table. Row values follow Columns.
+type TableDiff struct {
+	Table    string      `json:"table"`
+	Columns  []string    `json:"columns"`
+	Key      []string    `json:"key"`
+	Inserted []RowChange `json:"inserted,omitempty"`
+	Updated  []RowChange `json:"updated,omitempty"`
+	Deleted  []RowChange `json:"deleted,omitempty"`
+
+	types []columnType
+}
+
+// RowChange is one inserted, updated or deleted row. Before is nil for inserted rows and
+// After for deleted ones; Changed lists the columns an update changed.
+type RowChange struct {
+	Key     string        `json:"key"`
+	Before  []interface{} `json:"before,omitempty"`
+	After   []interface{} `json:"after,omitempty"`
+	Changed []string      `json:"changed,omitempty"`
+}
+
+// Table returns the changes of the named table, or nil when it did not change
+func (d *SnapshotDiff) Table(name string) *TableDiff {
+	for _, table := range d.Tables {
+		if table.Table == name {
+			return table
+		}
+	}
+	return nil
+}
+
+// Empty reports whether nothing changed
+func (d *SnapshotDiff) Empty() bool {
+	return len(d.Tables) == 0
+}
+
+// Diff compares two snapshots of the same database and reports the rows inserted, updated
+// and deleted in each table, matched by primary key. Only tables that changed are listed.
+func Diff(before, after *Snapshot) (*SnapshotDiff, error) {
+	diff := &SnapshotDiff{}
+
+	names := make([]string, 0, len(after.Tables))
+	seen := map[string]bool{}
+	for _, snapshot := range []*Snapshot{after, before} {
+		for _, table := range snapshot.Tables {
+			if !seen[table.Table] {
+				seen[table.Table] = true
+				names = append(names, table.Table)
+			}
+		}
+	}
+
+	for _, name := range names {
+		td, err := diffTable(before.Table(name), after.Table(name), after.Dialect)
+		if err != nil {
+			return nil, err
+		}
+		if len(td.Inserted)+len(td.Updated)+len(td.Deleted) > 0 {
+			diff.Tables = append(diff.Tables, td)
+		}
+	}
+	return diff, nil
+}
+
+// diffTable compares the rows of one table; either side may be nil when the table only
+// exists in the other snapshot
+func diffTable(before, after *TableSnapshot, dialect Dialect) (*TableDiff, error) {
+	current := after
+	if current == nil {
+		current = before
+	}
+	types, err := current.columnTypes(dialect)
+	if err != nil {
+		return nil, err
+	}
+	td := &TableDiff{Table: current.Table, Columns: current.Columns, Key: current.Key, types: types}
+
+	index := make(map[string]int, len(current.Columns))
+	for i, column := range current.Columns {
+		index[column] = i
+	}
+	keyOf := func(table *TableSnapshot, row []interface{}) (string, []interface{}, error) {
+		values := make([]interface{}, len(table.Key))
+		keyTypes := make([]columnType, len(table.Key))
+		for i, column := range table.Key {
+			j, ok := index[column]
+			if !ok {
+				return "", nil, fmt.Errorf("table %s has no key column %s", table.Table, column)
+			}
+			values[i] = row[j]
+			keyTypes[i] = types[j]
+		}
+		canonical, err := canonicalRow(values, keyTypes)
+		return canonical, values, err
+	}
+
+	// Rows of the older snapshot are aligned to the newer one's columns by name
+	beforeRows := map[string][]interface{}{}
+	var beforeOrder []string
+	if before != nil {
+		for _, row := range before.Rows {
+			aligned := alignRow(before, row, current.Columns)
+			key, _, err := keyOf(current, aligned)
+			if err != nil {
+				return nil, err
+			}
+			beforeRows[key] = aligned
+			beforeOrder = append(beforeOrder, key)
+		}
+	}
+
+	afterKeys := map[string]bool{}
+	if after != nil {
+		for _, row := range after.Rows {
+			key, values, err := keyOf(current, row)
+			if err != nil {
+				return nil, err
+			}
+			afterKeys[key] = true
+
+			old, ok := beforeRows[key]
+			if !ok {
+				td.Inserted = append(td.Inserted, RowChange{Key: formatKey(values), After: row})
+				continue
+			}
+
+			var changed []string
+			for i, column := range current.Columns {
+				equal, err := valuesEqual(old[i], row[i], types[i])
+				if err != nil {
+					return nil, fmt.Errorf("table %s column %s: %w", current.Table, column, err)
+				}
+				if !equal {
+					changed = append(changed, column)
+				}
+			}
+			if len(changed) > 0 {
+				td.Updated = append(td.Updated, RowChange{Key: formatKey(values), Before: old, After: row, Changed: changed})
+			}
+		}
+	}
+
+	for _, key := range beforeOrder {
+		if !afterKeys[key] {
+			row := beforeRows[key]
+			_, values, _ := keyOf(current, row)
+			td.Deleted = append(td.Deleted, RowChange{Key: formatKey(values), Before: row})
+		}
+	}
+	return td, nil
+}
+
+// alignRow returns the values of a row in the given column order; columns the table did
+// not have are NULL
+func alignRow(table *TableSnapshot, row []interface{}, columns []string) []interface{} {
+	aligned := make([]interface{}, len(columns))
+	for i, column := range columns {
+		for j, name := range table.Columns {
+			if name == column {
+				aligned[i] = row[j]
+				break
+			}
+		}
+	}
+	return aligned
+}
@@ -0,0 +1,196 @@
+package spanwright
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+)
+
+func testSnapshots() (*Snapshot, *Snapshot) {
+	before := &Snapshot{DatabaseID: "secondary-db", Dialect: DialectGoogleSQL, Tables: []*TableSnapshot{
+		{
+			Table:   "Users",
+			Columns: []string{"UserID", "Status", "UpdatedAt"},
+			Types:   []string{"STRING(36)", "INT64", "TIMESTAMP"},
+			Key:     []string{"UserID"},
+			Rows: [][]interface{}{
+				{"user-001", int64(1), "2024-01-01T00:00:00Z"},
+				{"user-002", int64(1), "2024-01-01T00:00:00Z"},
+				{"user-003", int64(1), "2024-01-01T00:00:00Z"},
+			},
+		},
+		{
+			Table:   "UserLogs",
+			Columns: []string{"LogID", "UserID", "Action"},
+			Types:   []string{"STRING(36)", "STRING(36)", "STRING(50)"},
+			Key:     []string{"LogID"},
+			Rows:    [][]interface{}{},
+		},
+	}}
+	after := &Snapshot{DatabaseID: "secondary-db", Dialect: DialectGoogleSQL, Tables: []*TableSnapshot{
+		{
+			Table:   "Users",
+			Columns: []string{"UserID", "Status", "UpdatedAt"},
+			Types:   []string{"STRING(36)", "INT64", "TIMESTAMP"},
+			Key:     []string{"UserID"},
+			Rows: [][]interface{}{
+				{"user-001", int64(2), "2024-06-01T12:00:00Z"},
+				{"user-002", 1, "2024-01-01T09:00:00+09:00"},
+			},
+		},
+		{
+			Table:   "UserLogs",
+			Columns: []string{"LogID", "UserID", "Action"},
+			Types:   []string{"STRING(36)", "STRING(36)", "STRING(50)"},
+			Key:     []string{"LogID"},
+			Rows:    [][]interface{}{{"log-001", "user-001", "login"}},
+		},
+	}}
+	return before, after
+}
+
+func TestDiff(t *testing.T) {
+	before, after := testSnapshots()
+
+	diff, err := Diff(before, after)
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if len(diff.Tables) != 2 {
+		t.Fatalf("Diff() tables = %d, want 2", len(diff.Tables))
+	}
+
+	users := diff.Table("Users")
+	if len(users.Inserted) != 0 || len(users.Updated) != 1 || len(users.Deleted) != 1 {
+		t.Fatalf("Users diff = %+v", users)
+	}
+	if users.Updated[0].Key != `"user-001"` || !reflect.DeepEqual(users.Updated[0].Changed, []string{"Status", "UpdatedAt"}) {
+		t.Errorf("Users update = %+v, want user-001 with Status and UpdatedAt changed", users.Updated[0])
+	}
+	if users.Deleted[0].Key != `"user-003"` {
+		t.Errorf("Users deletion = %+v, want user-003", users.Deleted[0])
+	}
+
+	logs := diff.Table("UserLogs")
+	if len(logs.Inserted) != 1 || logs.Inserted[0].Key != `"log-001"` {
+		t.Errorf("UserLogs diff = %+v, want log-001 inserted", logs)
+	}
+
+	same, err := Diff(after, after)
+	if err != nil || !same.Empty() {
+		t.Errorf("Diff() of the same snapshot = %+v, %v, want empty", same, err)
+	}
+}
+
+func TestSnapshotFile(t *testing.T) {
+	_, after := testSnapshots()
+	after.TakenAt = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	after.Tables[0].Rows = append(after.Tables[0].Rows, []interface{}{"user-004", nil, "2024-06-01T12:00:00.123456789Z"})
+
+	path := filepath.Join(t.TempDir(), "baseline", "secondary.yaml")
+	if err := after.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	loaded, err := LoadSnapshotFile(path)
+	if err != nil {
+		t.Fatalf("LoadSnapshotFile() error = %v", err)
+	}
+
+	if loaded.DatabaseID != "secondary-db" || !loaded.TakenAt.Equal(after.TakenAt) {
+		t.Errorf("LoadSnapshotFile() = %+v", loaded)
+	}
+	diff, err := Diff(after, loaded)
+	if err != nil || !diff.Empty() {
+		t.Errorf("Diff() after a round trip = %+v, %v, want empty", diff, err)
+	}
+}
+
+func TestRestoreMutations(t *testing.T) {
+	schema := testSchema()
+	schema.Dialect = DialectGoogleSQL
+	snapshot := &Snapshot{Dialect: DialectGoogleSQL, Tables: []*TableSnapshot{{
+		Table:   "Users",
+		Columns: []string{"UserID", "Name", "NameLength"},
+		Types:   []string{"STRING(36)", "STRING(MAX)", "INT64"},
+		Key:     []string{"UserID"},
+		Rows:    [][]interface{}{{"user-001", "Alice", int64(5)}, {"user-002", nil, nil}},
+	}}}
+
+	deletes, inserts, err := restoreMutations(snapshot, schema)
+	if err != nil {
+		t.Fatalf("restoreMutations() error = %v", err)
+	}
+
+	// Referencing and interleaved tables are cleared before the tables they depend on
+	wantDeletes := []*spanner.Mutation{
+		spanner.Delete("sales.Orders", spanner.AllKeys()),
+		spanner.Delete("sales.Products", spanner.AllKeys()),
+		spanner.Delete("Posts", spanner.AllKeys()),
+		spanner.Delete("Users", spanner.AllKeys()),
+	}
+	if !reflect.DeepEqual(deletes, wantDeletes) {
+		t.Errorf("restoreMutations() deletes = %v, want %v", deletes, wantDeletes)
+	}
+
+	// The generated NameLength column is left out
+	wantInserts := []*spanner.Mutation{
+		spanner.Insert("Users", []string{"UserID", "Name"}, []interface{}{"user-001", "Alice"}),
+		spanner.Insert("Users", []string{"UserID", "Name"}, []interface{}{"user-002", spanner.NullString{}}),
+	}
+	if len(inserts) != 1 || inserts[0].table != "Users" || !reflect.DeepEqual(inserts[0].inserts, wantInserts) {
+		t.Errorf("restoreMutations() inserts = %v, want %v", inserts, wantInserts)
+	}
+}
+
+func TestRestoreMutationsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		snapshot *Snapshot
+		want     string
+	}{
+		{
+			name:     "dialect",
+			snapshot: &Snapshot{Dialect: DialectPostgreSQL},
+			want:     "cannot be restored into a GOOGLE_STANDARD_SQL database",
+		},
+		{
+			name:     "unknown table",
+			snapshot: &Snapshot{Tables: []*TableSnapshot{{Table: "Carts"}}},
+			want:     "snapshot table Carts does not exist",
+		},
+		{
+			name: "unknown column",
+			snapshot: &Snapshot{Tables: []*TableSnapshot{{
+				Table:   "Users",
+				Columns: []string{"UserID", "Email"},
+				Types:   []string{"STRING(36)", "STRING(MAX)"},
+			}}},
+			want: "snapshot column Email does not exist in table Users",
+		},
+		{
+			name: "bad value",
+			snapshot: &Snapshot{Tables: []*TableSnapshot{{
+				Table:   "Users",
+				Columns: []string{"UserID", "Name"},
+				Types:   []string{"STRING(36)", "STRING(MAX)"},
+				Rows:    [][]interface{}{{"user-001", []interface{}{"Alice"}}},
+			}}},
+			want: "snapshot table Users row 1 column Name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := testSchema()
+			schema.Dialect = DialectGoogleSQL
+			_, _, err := restoreMutations(tt.snapshot, schema)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("restoreMutations() error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
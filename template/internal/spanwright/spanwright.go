@@ -6,77 +6,54 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"regexp"
 	"sort"
 	"strconv"
-	"strings"
+	"sync"
 	"time"
 
 	"cloud.google.com/go/spanner"
-	"github.com/joho/godotenv"
-	"google.golang.org/api/iterator"
 	"google.golang.org/grpc/codes"
 )
 
-// Basic validation patterns
-var (
-	// Basic ID validation
-	basicIDRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`)
-)
-
-// ValidateSpannerIDs validates basic Spanner resource identifiers
+// ValidateSpannerIDs validates Spanner resource identifiers against GCP naming rules
 func ValidateSpannerIDs(projectID, instanceID, databaseID string) error {
-	if err := ValidateBasicID(projectID, "project ID"); err != nil {
+	if err := ValidateProjectID(projectID); err != nil {
 		return err
 	}
-	
-	if err := ValidateBasicID(instanceID, "instance ID"); err != nil {
+
+	if err := ValidateInstanceID(instanceID); err != nil {
 		return err
 	}
-	
-	if err := ValidateBasicID(databaseID, "database ID"); err != nil {
+
+	if err := ValidateDatabaseID(databaseID); err != nil {
 		return err
 	}
-	
-	return nil
-}
 
-// ValidateBasicID validates basic identifier format
-func ValidateBasicID(id, fieldName string) error {
-	if id == "" {
-		return fmt.Errorf("%s cannot be empty", fieldName)
-	}
-	
-	if !basicIDRegex.MatchString(id) {
-		return fmt.Errorf("%s must start with a letter and contain only letters, numbers, hyphens, and underscores", fieldName)
-	}
-	
 	return nil
 }
 
 // BuildDSN constructs a Database Service Name (DSN) for Spanner
 func BuildDSN(projectID, instanceID, databaseID string) (string, error) {
-	// Basic validation
-	if err := ValidateSpannerIDs(projectID, instanceID, databaseID); err != nil {
-		return "", fmt.Errorf("DSN validation failed: %w", err)
-	}
-	
-	// Construct DSN
-	dsn := "projects/" + projectID + "/instances/" + instanceID + "/databases/" + databaseID
-	return dsn, nil
+	return BuildSecureDSN(projectID, instanceID, databaseID)
 }
 
 // Config represents the complete application configuration
 type Config struct {
-	ProjectID       string
-	InstanceID      string
-	EmulatorHost    string
-	PrimaryDB       string
-	SecondaryDB     string
-	PrimarySchema   string
-	SecondarySchema string
-	Environment     string
-	Timeout         int
+	ProjectID            string
+	InstanceID           string
+	EmulatorHost         string
+	AllowedEmulatorHosts []string
+	AllowNonEmulator     string
+	Databases            []DatabaseSpec
+	Environment          string
+	Timeout              int
+	ScenariosDir         string
+	Retry                RetryPolicy
+
+	// settings records the resolved value and source of every configuration key
+	settings map[string]ConfigSetting
+	// worker is the WORKER_INDEX whose database clones Databases refers to; see ForWorker
+	worker *int
 }
 
 // SecureConfig represents a configuration with enhanced security validation
@@ -97,6 +74,13 @@ type DatabaseConfig struct {
 	ProjectID  string
 	InstanceID string
 	DatabaseID string
+	// Dialect is used when the database has to be created; an existing database keeps its own
+	Dialect Dialect
+	Retry   RetryPolicy
+	// Timeout bounds every DatabaseManager operation; zero means no limit
+	Timeout time.Duration
+	// Guard restricts connections to the emulator; nil means loopback hosts only
+	Guard *EmulatorGuard
 }
 
 // DatabasePath returns the full database path
@@ -109,68 +93,100 @@ func (dc *DatabaseConfig) DatabasePath() string {
 	return dsn
 }
 
-// LoadConfig loads configuration from environment variables
+// LoadConfig loads configuration from spanwright.yaml, .env and environment variables
 func LoadConfig() (*Config, error) {
-	_ = godotenv.Load()
+	return LoadConfigWithOptions(LoadOptions{})
+}
 
-	config := &Config{
-		ProjectID:       os.Getenv("PROJECT_ID"),
-		InstanceID:      os.Getenv("INSTANCE_ID"),
-		EmulatorHost:    os.Getenv("SPANNER_EMULATOR_HOST"),
-		PrimaryDB:       os.Getenv("PRIMARY_DATABASE_ID"),
-		SecondaryDB:     os.Getenv("SECONDARY_DATABASE_ID"),
-		PrimarySchema:   os.Getenv("PRIMARY_SCHEMA_PATH"),
-		SecondarySchema: os.Getenv("SECONDARY_SCHEMA_PATH"),
-		Environment:     getEnvWithDefault("ENVIRONMENT", "development"),
-		Timeout:         getEnvIntWithDefault("TIMEOUT_SECONDS", 120),
+// LoadConfigWithOptions loads configuration, resolving each value in the order
+// defaults < config file < .env < environment < flags
+func LoadConfigWithOptions(opts LoadOptions) (*Config, error) {
+	config, err := resolveConfig(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
 	}
 
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("configuration validation failed: %w", err)
 	}
 
+	// WORKER_INDEX points every command at one worker's clones of the databases
+	if config.Source("WORKER_INDEX") != "" {
+		value := config.settings["WORKER_INDEX"].Value
+		worker, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("configuration validation failed: WORKER_INDEX: invalid integer %q", value)
+		}
+		if config, err = config.ForWorker(worker); err != nil {
+			return nil, fmt.Errorf("configuration validation failed: WORKER_INDEX: %w", err)
+		}
+	}
+
 	return config, nil
 }
 
 // LoadSecureConfig loads configuration with enhanced security validation
 func LoadSecureConfig() (*SecureConfig, error) {
-	_ = godotenv.Load()
-
-	config := &SecureConfig{
-		ProjectID:       os.Getenv("PROJECT_ID"),
-		InstanceID:      os.Getenv("INSTANCE_ID"),
-		EmulatorHost:    os.Getenv("SPANNER_EMULATOR_HOST"),
-		PrimaryDB:       os.Getenv("PRIMARY_DATABASE_ID"),
-		SecondaryDB:     os.Getenv("SECONDARY_DATABASE_ID"),
-		PrimarySchema:   os.Getenv("PRIMARY_SCHEMA_PATH"),
-		SecondarySchema: os.Getenv("SECONDARY_SCHEMA_PATH"),
-		Environment:     getEnvWithDefault("ENVIRONMENT", "development"),
-		Timeout:         getEnvIntWithDefault("TIMEOUT_SECONDS", 120),
+	config, err := resolveConfig(LoadOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	secureConfig := &SecureConfig{
+		ProjectID:    config.ProjectID,
+		InstanceID:   config.InstanceID,
+		EmulatorHost: config.EmulatorHost,
+		Environment:  config.Environment,
+		Timeout:      config.Timeout,
+	}
+	for _, spec := range config.Databases {
+		switch spec.Name {
+		case "primary":
+			secureConfig.PrimaryDB, secureConfig.PrimarySchema = spec.DatabaseID, spec.SchemaPath
+		case "secondary":
+			secureConfig.SecondaryDB, secureConfig.SecondarySchema = spec.DatabaseID, spec.SchemaPath
+		}
 	}
 
-	if err := config.ValidateSecure(); err != nil {
+	if err := secureConfig.ValidateSecure(); err != nil {
 		return nil, fmt.Errorf("secure configuration validation failed: %w", err)
 	}
 
-	return config, nil
+	return secureConfig, nil
 }
 
 // ToConfig converts SecureConfig to regular Config
 func (sc *SecureConfig) ToConfig() *Config {
+	databases := []DatabaseSpec{{
+		Name:         "primary",
+		DatabaseID:   sc.PrimaryDB,
+		SchemaPath:   sc.PrimarySchema,
+		FixtureDir:   sc.PrimaryDB,
+		ExpectedFile: "expected-primary.yaml",
+	}}
+	if sc.SecondaryDB != "" {
+		databases = append(databases, DatabaseSpec{
+			Name:         "secondary",
+			DatabaseID:   sc.SecondaryDB,
+			SchemaPath:   sc.SecondarySchema,
+			FixtureDir:   sc.SecondaryDB,
+			ExpectedFile: "expected-secondary.yaml",
+		})
+	}
+
 	return &Config{
-		ProjectID:       sc.ProjectID,
-		InstanceID:      sc.InstanceID,
-		EmulatorHost:    sc.EmulatorHost,
-		PrimaryDB:       sc.PrimaryDB,
-		SecondaryDB:     sc.SecondaryDB,
-		PrimarySchema:   sc.PrimarySchema,
-		SecondarySchema: sc.SecondarySchema,
-		Environment:     sc.Environment,
-		Timeout:         sc.Timeout,
+		ProjectID:    sc.ProjectID,
+		InstanceID:   sc.InstanceID,
+		EmulatorHost: sc.EmulatorHost,
+		Databases:    databases,
+		Environment:  sc.Environment,
+		Timeout:      sc.Timeout,
+		ScenariosDir: configDefaults["SCENARIOS_DIR"],
+		Retry:        DefaultRetryPolicy,
 	}
 }
 
-// ValidateSecure performs basic validation on SecureConfig
+// ValidateSecure performs strict validation on SecureConfig
 func (sc *SecureConfig) ValidateSecure() error {
 	// Validate required fields
 	if sc.ProjectID == "" {
@@ -185,27 +201,48 @@ func (sc *SecureConfig) ValidateSecure() error {
 	if sc.PrimarySchema == "" {
 		return fmt.Errorf("PRIMARY_SCHEMA_PATH is required")
 	}
-	
-	// Basic validation for Spanner IDs
-	if err := ValidateBasicID(sc.ProjectID, "PROJECT_ID"); err != nil {
-		return err
+
+	// Spanner naming rules
+	if err := ValidateProjectID(sc.ProjectID); err != nil {
+		return fmt.Errorf("PROJECT_ID: %w", err)
 	}
-	
-	if err := ValidateBasicID(sc.InstanceID, "INSTANCE_ID"); err != nil {
-		return err
+
+	if err := ValidateInstanceID(sc.InstanceID); err != nil {
+		return fmt.Errorf("INSTANCE_ID: %w", err)
 	}
-	
-	if err := ValidateBasicID(sc.PrimaryDB, "PRIMARY_DATABASE_ID"); err != nil {
-		return err
+
+	if err := ValidateDatabaseID(sc.PrimaryDB); err != nil {
+		return fmt.Errorf("PRIMARY_DATABASE_ID: %w", err)
 	}
-	
+
 	// Validate secondary database if provided
 	if sc.SecondaryDB != "" {
-		if err := ValidateBasicID(sc.SecondaryDB, "SECONDARY_DATABASE_ID"); err != nil {
-			return err
+		if err := ValidateDatabaseID(sc.SecondaryDB); err != nil {
+			return fmt.Errorf("SECONDARY_DATABASE_ID: %w", err)
 		}
 	}
-	
+
+	// Refuse anything that looks like a production target
+	if err := validateNonProductionID(sc.ProjectID, "PROJECT_ID"); err != nil {
+		return err
+	}
+
+	if err := validateNonProductionID(sc.InstanceID, "INSTANCE_ID"); err != nil {
+		return err
+	}
+
+	if err := validateEmulatorHost(sc.EmulatorHost); err != nil {
+		return fmt.Errorf("SPANNER_EMULATOR_HOST: %w", err)
+	}
+
+	if err := validateEnvironment(sc.Environment); err != nil {
+		return fmt.Errorf("ENVIRONMENT: %w", err)
+	}
+
+	if sc.Timeout < 1 || sc.Timeout > 3600 {
+		return fmt.Errorf("TIMEOUT_SECONDS must be between 1 and 3600, got %d", sc.Timeout)
+	}
+
 	return nil
 }
 
@@ -218,54 +255,91 @@ func (c *Config) Validate() error {
 	if c.InstanceID == "" {
 		return fmt.Errorf("INSTANCE_ID is required")
 	}
-	if c.PrimaryDB == "" {
-		return fmt.Errorf("PRIMARY_DATABASE_ID is required")
-	}
-	if c.PrimarySchema == "" {
-		return fmt.Errorf("PRIMARY_SCHEMA_PATH is required")
+
+	// Spanner naming rules
+	if err := ValidateProjectID(c.ProjectID); err != nil {
+		return fmt.Errorf("PROJECT_ID: %w", err)
 	}
-	
-	// Basic validation for Spanner IDs
-	if err := ValidateBasicID(c.ProjectID, "PROJECT_ID"); err != nil {
-		return err
+
+	if err := ValidateInstanceID(c.InstanceID); err != nil {
+		return fmt.Errorf("INSTANCE_ID: %w", err)
 	}
-	
-	if err := ValidateBasicID(c.InstanceID, "INSTANCE_ID"); err != nil {
-		return err
+
+	if err := c.Retry.Validate(); err != nil {
+		return fmt.Errorf("retry policy: %w", err)
 	}
-	
-	if err := ValidateBasicID(c.PrimaryDB, "PRIMARY_DATABASE_ID"); err != nil {
-		return err
+
+	return validateDatabaseSpecs(c.Databases)
+}
+
+// ScenarioDir returns the directory of a scenario under the scenarios root
+func (c *Config) ScenarioDir(scenario string) string {
+	return filepath.Join(c.ScenariosDir, scenario)
+}
+
+// EmulatorGuard returns the emulator guard described by the configuration
+func (c *Config) EmulatorGuard() *EmulatorGuard {
+	return &EmulatorGuard{
+		AllowedHosts:     c.AllowedEmulatorHosts,
+		AllowNonEmulator: c.AllowNonEmulator,
+		Environment:      c.Environment,
 	}
-	
-	// Validate secondary database if provided
-	if c.SecondaryDB != "" {
-		if err := ValidateBasicID(c.SecondaryDB, "SECONDARY_DATABASE_ID"); err != nil {
-			return err
+}
+
+// Database returns the configured database with the given logical name or database ID
+func (c *Config) Database(nameOrID string) (*DatabaseSpec, error) {
+	for i := range c.Databases {
+		if c.Databases[i].Name == nameOrID || c.Databases[i].DatabaseID == nameOrID {
+			return &c.Databases[i], nil
 		}
 	}
-	
-	return nil
+	return nil, fmt.Errorf("database %q is not configured", nameOrID)
 }
 
+// GetDatabaseConfig returns a DatabaseConfig for the configured database with the given
+// logical name or database ID
+func (c *Config) GetDatabaseConfig(nameOrID string) (*DatabaseConfig, error) {
+	spec, err := c.Database(nameOrID)
+	if err != nil {
+		return nil, err
+	}
 
-// GetDatabaseConfig returns a DatabaseConfig for the specified database ID
-func (c *Config) GetDatabaseConfig(databaseID string) *DatabaseConfig {
 	return &DatabaseConfig{
 		ProjectID:  c.ProjectID,
 		InstanceID: c.InstanceID,
-		DatabaseID: databaseID,
-	}
+		DatabaseID: spec.DatabaseID,
+		Dialect:    spec.Dialect,
+		Retry:      c.Retry,
+		Timeout:    c.TimeoutDuration(),
+		Guard:      c.EmulatorGuard(),
+	}, nil
 }
 
 // DatabaseManager manages Spanner database operations
 type DatabaseManager struct {
 	config *DatabaseConfig
 	client *spanner.Client
+
+	// dialect caches the detected database dialect; see Dialect
+	dialectMu sync.Mutex
+	dialect   Dialect
+
+	// seeded records the rows seeded through this manager; see SeededRows
+	seededMu sync.Mutex
+	seeded   *SeededRows
 }
 
-// NewDatabaseManager creates a new DatabaseManager
+// NewDatabaseManager creates a new DatabaseManager. It refuses to create a client unless
+// SPANNER_EMULATOR_HOST points at an emulator accepted by the configured guard.
 func NewDatabaseManager(ctx context.Context, dbConfig *DatabaseConfig) (*DatabaseManager, error) {
+	guard := dbConfig.Guard
+	if guard == nil {
+		guard = &EmulatorGuard{}
+	}
+	if err := guard.CheckEnvironment(); err != nil {
+		return nil, err
+	}
+
 	client, err := spanner.NewClient(ctx, dbConfig.DatabasePath())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Spanner client: %w", err)
@@ -277,6 +351,17 @@ func NewDatabaseManager(ctx context.Context, dbConfig *DatabaseConfig) (*Databas
 	}, nil
 }
 
+// WithRetryPolicy returns a DatabaseManager sharing the same client that retries with
+// policy instead of the configured one. Close only the original manager.
+func (dm *DatabaseManager) WithRetryPolicy(policy RetryPolicy) *DatabaseManager {
+	config := *dm.config
+	config.Retry = policy
+
+	dm.dialectMu.Lock()
+	defer dm.dialectMu.Unlock()
+	return &DatabaseManager{config: &config, client: dm.client, dialect: dm.dialect}
+}
+
 // Close closes the Spanner client
 func (dm *DatabaseManager) Close() error {
 	if dm.client != nil {
@@ -285,28 +370,18 @@ func (dm *DatabaseManager) Close() error {
 	return nil
 }
 
-// ListTables returns all table names in the database
+// ListTables returns all table names in the default schema of the database
 func (dm *DatabaseManager) ListTables(ctx context.Context) ([]string, error) {
-	stmt := spanner.NewStatement("SELECT table_name FROM information_schema.tables WHERE table_schema = '' ORDER BY table_name")
-	iter := dm.client.Single().Query(ctx, stmt)
-	defer iter.Stop()
-
-	var tables []string
-	for {
-		row, err := iter.Next()
-		if err != nil {
-			if err == iterator.Done {
-				break
-			}
-			return nil, fmt.Errorf("error listing tables: %w", err)
-		}
+	dialect, err := dm.Dialect(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-		var tableName string
-		if err := row.Columns(&tableName); err != nil {
-			log.Printf("Warning: error reading table name: %v", err)
-			continue
-		}
-		tables = append(tables, tableName)
+	stmt := dialect.Statement("SELECT table_name FROM information_schema.tables WHERE table_schema = "+dialect.Placeholder(1)+" ORDER BY table_name",
+		dialect.DefaultSchema())
+	tables, err := QueryRows[string](ctx, dm, stmt)
+	if err != nil {
+		return nil, fmt.Errorf("error listing tables: %w", err)
 	}
 
 	sort.Strings(tables)
@@ -323,134 +398,57 @@ func (dm *DatabaseManager) GetTableRowCount(ctx context.Context, tableName strin
 		return 0, fmt.Errorf("invalid table name: %w", err)
 	}
 
-	// Use parameterized query to prevent SQL injection
-	// Note: Spanner doesn't support parameterized table names, so we use validation + escaping
-	escapedTableName := escapeIdentifier(tableName)
-	stmt := spanner.NewStatement(fmt.Sprintf("SELECT COUNT(*) FROM `%s`", escapedTableName))
-	iter := dm.client.Single().Query(ctx, stmt)
-	defer iter.Stop()
-
-	row, err := iter.Next()
+	dialect, err := dm.Dialect(ctx)
 	if err != nil {
-		return 0, fmt.Errorf("failed to execute count query for table %s: %w", tableName, err)
+		return 0, err
 	}
 
-	var count int64
-	if err := row.Columns(&count); err != nil {
-		return 0, fmt.Errorf("failed to read count result for table %s: %w", tableName, err)
+	// Use parameterized query to prevent SQL injection
+	// Note: Spanner doesn't support parameterized table names, so we use validation + quoting
+	stmt := spanner.NewStatement("SELECT COUNT(*) FROM " + dialect.QuoteIdentifier(tableName))
+	count, err := QueryOne[int64](ctx, dm, stmt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count rows of table %s: %w", tableName, err)
 	}
 
 	return count, nil
 }
 
-// ApplyMutations applies mutations to the database with retry logic
+// ApplyMutations applies mutations to the database with retry logic. A commit rejected
+// because a row already exists is logged and skipped as a whole.
 func (dm *DatabaseManager) ApplyMutations(ctx context.Context, mutations []*spanner.Mutation) error {
-	if len(mutations) == 0 {
+	err := dm.applyMutations(ctx, mutations)
+	if spanner.ErrCode(err) == codes.AlreadyExists {
+		log.Printf("Warning: Some data already exists, continuing...")
 		return nil
 	}
-
-	return WithRetry(ctx, "Apply Mutations", func(ctx context.Context, attempt int) error {
-		_, err := dm.client.Apply(ctx, mutations)
-		if err != nil {
-			if spanner.ErrCode(err) == codes.AlreadyExists {
-				log.Printf("Warning: Some data already exists, continuing...")
-				return nil
-			}
-			return err
-		}
-		return nil
-	})
+	return err
 }
 
-// Query executes a query with retry logic
-func (dm *DatabaseManager) Query(ctx context.Context, stmt spanner.Statement) (*spanner.RowIterator, error) {
-	var iter *spanner.RowIterator
-	err := WithRetry(ctx, "Query", func(ctx context.Context, attempt int) error {
-		iter = dm.client.Single().Query(ctx, stmt)
+// applyMutations applies mutations in one commit with retry logic, reporting every error
+func (dm *DatabaseManager) applyMutations(ctx context.Context, mutations []*spanner.Mutation) error {
+	if len(mutations) == 0 {
 		return nil
-	})
-	return iter, err
-}
-
-// WithRetry executes a function with simple retry logic
-func WithRetry(ctx context.Context, operation string, fn func(context.Context, int) error) error {
-	maxAttempts := 3
-	initialDelay := 100 * time.Millisecond
-
-	var lastErr error
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		err := fn(ctx, attempt)
-		if err == nil {
-			return nil
-		}
-
-		lastErr = err
-
-		if !isRetryableError(err) {
-			return err
-		}
-
-		if attempt == maxAttempts {
-			break
-		}
-
-		delay := time.Duration(attempt) * initialDelay
-		log.Printf("⚠️ %s failed on attempt %d/%d, retrying in %v: %v", operation, attempt, maxAttempts, delay, err)
-
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(delay):
-		}
 	}
 
-	return fmt.Errorf("max retry attempts (%d) exceeded: %w", maxAttempts, lastErr)
-}
+	ctx, cancel := dm.operationContext(ctx)
+	defer cancel()
 
-// isRetryableError determines if an error should be retried
-func isRetryableError(err error) bool {
-	if err == nil {
-		return false
-	}
-
-	if err == context.Canceled || err == context.DeadlineExceeded {
-		return false
-	}
-
-	spannerCode := spanner.ErrCode(err)
-	switch spannerCode {
-	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal:
-		return true
-	default:
-		return false
-	}
-}
-
-// ValidateTableName validates basic table name format
-func ValidateTableName(tableName string) error {
-	if tableName == "" {
-		return fmt.Errorf("table name cannot be empty")
-	}
-
-	if len(tableName) > 128 {
-		return fmt.Errorf("table name exceeds maximum length of 128 characters")
-	}
-
-	if !basicIDRegex.MatchString(tableName) {
-		return fmt.Errorf("table name must start with letter and contain only letters, digits, underscores, and hyphens")
-	}
-
-	return nil
+	return dm.config.Retry.Do(ctx, "Apply Mutations", func(ctx context.Context, attempt int) error {
+		_, err := dm.client.Apply(ctx, mutations)
+		return err
+	})
 }
 
-
-// escapeIdentifier escapes an identifier for safe use in SQL
-func escapeIdentifier(identifier string) string {
-	return strings.ReplaceAll(identifier, "`", "``")
+// Query starts a query in a single-use read-only transaction. Errors only surface from the
+// iterator and are not retried; prefer QueryRows, QueryOne or QueryEach.
+func (dm *DatabaseManager) Query(ctx context.Context, stmt spanner.Statement) (*spanner.RowIterator, error) {
+	return dm.client.Single().Query(ctx, stmt), nil
 }
 
-// ReadSchemaFiles reads SQL schema files from a directory
-func ReadSchemaFiles(schemaPath string) ([]string, error) {
+// ReadSchemaFiles reads the *.sql files of a directory in name order and splits them
+// into individual DDL statements of the given dialect
+func ReadSchemaFiles(schemaPath string, dialect Dialect) ([]DDLStatement, error) {
 	if schemaPath == "" {
 		return nil, fmt.Errorf("schema path cannot be empty")
 	}
@@ -465,31 +463,19 @@ func ReadSchemaFiles(schemaPath string) ([]string, error) {
 		return nil, fmt.Errorf("failed to list schema files: %w", err)
 	}
 
-	var ddlStatements []string
+	var ddlStatements []DDLStatement
 	for _, file := range files {
 		content, err := os.ReadFile(file)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read schema file %s: %w", file, err)
 		}
-		ddlStatements = append(ddlStatements, string(content))
+
+		statements, err := SplitDDL(file, string(content), dialect)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse schema file: %w", err)
+		}
+		ddlStatements = append(ddlStatements, statements...)
 	}
 
 	return ddlStatements, nil
 }
-
-// Helper functions
-func getEnvWithDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
-
-func getEnvIntWithDefault(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if parsed, err := strconv.Atoi(value); err == nil {
-			return parsed
-		}
-	}
-	return defaultValue
-}
\ No newline at end of file
@@ -0,0 +1,157 @@
+package spanwright
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/spanner"
+)
+
+// ReadWriteOptions configures DatabaseManager.ReadWrite
+type ReadWriteOptions struct {
+	// TransactionTag is attached to the transaction for query statistics and tracing
+	TransactionTag string
+	// RequestTag is attached to every statement run through the Tx
+	RequestTag string
+	// ReturnCommitStats asks Spanner for the number of mutations in the commit
+	ReturnCommitStats bool
+	// Retry overrides the manager's retry policy for this transaction
+	Retry *RetryPolicy
+}
+
+// retryPolicy returns the policy of the transaction: Retry when set, otherwise fallback
+func (opts ReadWriteOptions) retryPolicy(fallback RetryPolicy) RetryPolicy {
+	if opts.Retry != nil {
+		return *opts.Retry
+	}
+	return fallback
+}
+
+// transactionOptions returns the Spanner options for the transaction tag and commit stats
+func (opts ReadWriteOptions) transactionOptions() spanner.TransactionOptions {
+	return spanner.TransactionOptions{
+		TransactionTag: opts.TransactionTag,
+		CommitOptions:  spanner.CommitOptions{ReturnCommitStats: opts.ReturnCommitStats},
+	}
+}
+
+// CommitResult describes a committed read-write transaction
+type CommitResult struct {
+	Timestamp time.Time
+	// MutationCount is only set when ReturnCommitStats was requested
+	MutationCount int64
+}
+
+// commitResult converts Spanner's commit response; the mutation count is only present when
+// commit stats were requested
+func commitResult(resp spanner.CommitResponse) *CommitResult {
+	result := &CommitResult{Timestamp: resp.CommitTs}
+	if resp.CommitStats != nil {
+		result.MutationCount = resp.CommitStats.GetMutationCount()
+	}
+	return result
+}
+
+// Tx is the read-write transaction passed to a ReadWrite callback
+type Tx struct {
+	txn        *spanner.ReadWriteTransaction
+	requestTag string
+}
+
+// queryOptions returns the per-statement options of the transaction
+func (tx *Tx) queryOptions() spanner.QueryOptions {
+	return spanner.QueryOptions{RequestTag: tx.requestTag}
+}
+
+// Exec runs a DML statement and returns the number of affected rows
+func (tx *Tx) Exec(ctx context.Context, stmt spanner.Statement) (int64, error) {
+	count, err := tx.txn.UpdateWithOptions(ctx, stmt, tx.queryOptions())
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute DML: %w", err)
+	}
+	return count, nil
+}
+
+// BatchExec runs DML statements in one round trip and returns the affected row count of
+// each. Execution stops at the first failing statement.
+func (tx *Tx) BatchExec(ctx context.Context, stmts ...spanner.Statement) ([]int64, error) {
+	if len(stmts) == 0 {
+		return nil, nil
+	}
+
+	counts, err := tx.txn.BatchUpdateWithOptions(ctx, stmts, tx.queryOptions())
+	if err != nil {
+		return counts, batchExecError(counts, len(stmts), err)
+	}
+	return counts, nil
+}
+
+// batchExecError names the failing statement of a batch: Spanner returns the counts of the
+// statements that succeeded before it
+func batchExecError(counts []int64, total int, err error) error {
+	return fmt.Errorf("failed to execute batch DML (statement %d of %d): %w", len(counts)+1, total, err)
+}
+
+// Buffer queues mutations to be applied when the transaction commits
+func (tx *Tx) Buffer(mutations ...*spanner.Mutation) error {
+	if err := tx.txn.BufferWrite(mutations); err != nil {
+		return fmt.Errorf("failed to buffer mutations: %w", err)
+	}
+	return nil
+}
+
+// Query reads within the transaction, calling fn for every row
+func (tx *Tx) Query(ctx context.Context, stmt spanner.Statement, fn func(*spanner.Row) error) error {
+	return tx.txn.QueryWithOptions(ctx, stmt, tx.queryOptions()).Do(fn)
+}
+
+// Raw returns the underlying Spanner transaction
+func (tx *Tx) Raw() *spanner.ReadWriteTransaction {
+	return tx.txn
+}
+
+// QueryRowsTx reads within a transaction and returns every row decoded into T
+func QueryRowsTx[T any](ctx context.Context, tx *Tx, stmt spanner.Statement) ([]T, error) {
+	var rows []T
+	err := tx.Query(ctx, stmt, func(row *spanner.Row) error {
+		value, err := decodeRow[T](row)
+		if err != nil {
+			return fmt.Errorf("failed to decode row: %w", err)
+		}
+		rows = append(rows, value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ReadWrite runs fn in a read-write transaction and commits it. fn may run more than once:
+// Spanner re-runs aborted transactions and transient failures are retried with the retry
+// policy, so fn must not have side effects outside the transaction.
+func (dm *DatabaseManager) ReadWrite(ctx context.Context, fn func(ctx context.Context, tx *Tx) error, opts ReadWriteOptions) (*CommitResult, error) {
+	ctx, cancel := dm.operationContext(ctx)
+	defer cancel()
+
+	txOptions := opts.transactionOptions()
+
+	var result *CommitResult
+	err := opts.retryPolicy(dm.config.Retry).Do(ctx, "Read-Write Transaction", func(ctx context.Context, attempt int) error {
+		resp, err := dm.client.ReadWriteTransactionWithOptions(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+			return fn(ctx, &Tx{txn: txn, requestTag: opts.RequestTag})
+		}, txOptions)
+		if err != nil {
+			return err
+		}
+
+		result = commitResult(resp)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read-write transaction failed: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,74 @@
+package spanwright
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	sppb "cloud.google.com/go/spanner/apiv1/spannerpb"
+)
+
+func TestReadWriteOptionsRetryPolicy(t *testing.T) {
+	configured := RetryPolicy{MaxAttempts: 5, InitialDelay: 100 * time.Millisecond}
+	override := RetryPolicy{MaxAttempts: 1}
+
+	if got := (ReadWriteOptions{}).retryPolicy(configured); !reflect.DeepEqual(got, configured) {
+		t.Errorf("retryPolicy() without Retry = %+v, want the configured policy", got)
+	}
+	if got := (ReadWriteOptions{Retry: &override}).retryPolicy(configured); !reflect.DeepEqual(got, override) {
+		t.Errorf("retryPolicy() with Retry = %+v, want %+v", got, override)
+	}
+}
+
+func TestReadWriteOptionsTransactionOptions(t *testing.T) {
+	opts := ReadWriteOptions{TransactionTag: "checkout", RequestTag: "step", ReturnCommitStats: true}
+	want := spanner.TransactionOptions{
+		TransactionTag: "checkout",
+		CommitOptions:  spanner.CommitOptions{ReturnCommitStats: true},
+	}
+	if got := opts.transactionOptions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("transactionOptions() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCommitResult(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	got := commitResult(spanner.CommitResponse{CommitTs: ts})
+	if want := (&CommitResult{Timestamp: ts}); !reflect.DeepEqual(got, want) {
+		t.Errorf("commitResult() without stats = %+v, want %+v", got, want)
+	}
+
+	got = commitResult(spanner.CommitResponse{CommitTs: ts, CommitStats: &sppb.CommitResponse_CommitStats{MutationCount: 7}})
+	if want := (&CommitResult{Timestamp: ts, MutationCount: 7}); !reflect.DeepEqual(got, want) {
+		t.Errorf("commitResult() with stats = %+v, want %+v", got, want)
+	}
+}
+
+func TestBatchExecError(t *testing.T) {
+	cause := errors.New("constraint violation")
+
+	tests := []struct {
+		name   string
+		counts []int64
+		total  int
+		want   string
+	}{
+		{name: "first statement", counts: nil, total: 3, want: "failed to execute batch DML (statement 1 of 3): constraint violation"},
+		{name: "later statement", counts: []int64{1, 4}, total: 3, want: "failed to execute batch DML (statement 3 of 3): constraint violation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := batchExecError(tt.counts, tt.total, cause)
+			if err.Error() != tt.want {
+				t.Errorf("batchExecError() = %q, want %q", err, tt.want)
+			}
+			if !errors.Is(err, cause) {
+				t.Error("batchExecError() does not wrap the cause")
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package spanwright
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"cloud.google.com/go/spanner"
+)
+
+// Truncate deletes every row of every table in one commit, children first so interleaved
+// and referencing rows go before their parents. The schema, including sequences, is left
+// unchanged.
+func (dm *DatabaseManager) Truncate(ctx context.Context) error {
+	ctx, cancel := dm.operationContext(ctx)
+	defer cancel()
+
+	schema, err := dm.DescribeSchema(ctx)
+	if err != nil {
+		return err
+	}
+
+	order, err := tableOrder(schema)
+	if err != nil {
+		return err
+	}
+
+	deletes := clearMutations(order)
+	if err := dm.ApplyMutations(ctx, deletes); err != nil {
+		return fmt.Errorf("failed to truncate tables of %s: %w", dm.config.DatabaseID, err)
+	}
+
+	log.Printf("🧹 Truncated %d tables of %s", len(deletes), dm.config.DatabaseID)
+	return nil
+}
+
+// tableOrder returns the tables of the schema parents first, following interleave and
+// foreign key relationships
+func tableOrder(schema *DatabaseSchema) ([]string, error) {
+	order, err := SortTablesByDependency(schema.TableNames(), schema.Dependencies())
+	if err != nil {
+		return nil, fmt.Errorf("failed to order tables: %w", err)
+	}
+	return order, nil
+}
+
+// clearMutations returns mutations deleting all rows of the tables, in the reverse of
+// their parents-first order
+func clearMutations(order []string) []*spanner.Mutation {
+	deletes := make([]*spanner.Mutation, 0, len(order))
+	for i := len(order) - 1; i >= 0; i-- {
+		deletes = append(deletes, spanner.Delete(order[i], spanner.AllKeys()))
+	}
+	return deletes
+}
@@ -0,0 +1,649 @@
+package spanwright
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"cloud.google.com/go/spanner"
+)
+
+// ValidationReport is the result of checking a database against an expected-state file
+type ValidationReport struct {
+	DatabaseID string              `json:"database_id"`
+	File       string              `json:"file,omitempty"`
+	Passed     bool                `json:"passed"`
+	Tables     []*TableResult      `json:"tables"`
+	Queries    []*QueryCheckResult `json:"queries,omitempty"`
+	Changes    []*TableResult      `json:"changes,omitempty"`
+}
+
+// TableResult is the result of checking one table
+type TableResult struct {
+	Table         string     `json:"table"`
+	Passed        bool       `json:"passed"`
+	ExpectedCount *int       `json:"expected_count,omitempty"`
+	ActualCount   int        `json:"actual_count"`
+	Mismatches    []Mismatch `json:"mismatches,omitempty"`
+}
+
+// Mismatch is one difference between the expected and the actual state. Row is the
+// 1-based row in primary key order, or 0 for differences that concern the whole table;
+// Key is the primary key of the row and ExpectedRow the 1-based entry of rows it concerns.
+type Mismatch struct {
+	Row         int         `json:"row,omitempty"`
+	Key         string      `json:"key,omitempty"`
+	ExpectedRow int         `json:"expected_row,omitempty"`
+	Column      string      `json:"column,omitempty"`
+	Expected    interface{} `json:"expected,omitempty"`
+	Actual      interface{} `json:"actual,omitempty"`
+	Message     string      `json:"message"`
+}
+
+// String formats the mismatch for logs
+func (m Mismatch) String() string {
+	var parts []string
+	if m.ExpectedRow > 0 {
+		parts = append(parts, fmt.Sprintf("expected row %d:", m.ExpectedRow))
+	}
+	if m.Key != "" {
+		parts = append(parts, "key "+m.Key)
+	} else if m.Row > 0 {
+		parts = append(parts, fmt.Sprintf("row %d", m.Row))
+	}
+	if m.Column != "" {
+		parts = append(parts, "column "+m.Column)
+	}
+	parts = append(parts, m.Message)
+	return strings.Join(parts, " ")
+}
+
+// Failures returns every mismatch of the report as "table: mismatch" lines
+func (r *ValidationReport) Failures() []string {
+	var failures []string
+	for _, table := range r.Tables {
+		for _, mismatch := range table.Mismatches {
+			failures = append(failures, table.Table+": "+mismatch.String())
+		}
+	}
+	for _, query := range r.Queries {
+		for _, mismatch := range query.Mismatches {
+			failures = append(failures, "query "+query.Query+": "+mismatch.String())
+		}
+	}
+	for _, table := range r.Changes {
+		for _, mismatch := range table.Mismatches {
+			failures = append(failures, "changes of "+table.Table+": "+mismatch.String())
+		}
+	}
+	return failures
+}
+
+// Validator checks the contents of a database against expected-state files
+type Validator struct {
+	dm *DatabaseManager
+	// databases are the other databases queries can name
+	databases map[string]*DatabaseManager
+	// baseline is the snapshot changes are measured from
+	baseline *Snapshot
+}
+
+// NewValidator creates a Validator that reads through dm
+func NewValidator(dm *DatabaseManager) *Validator {
+	return &Validator{dm: dm, databases: map[string]*DatabaseManager{}}
+}
+
+// WithDatabase lets queries in expected-state files read the database with the given
+// logical name
+func (v *Validator) WithDatabase(name string, dm *DatabaseManager) *Validator {
+	v.databases[name] = dm
+	return v
+}
+
+// WithBaseline sets the snapshot that the changes section of expected-state files is
+// checked against, usually taken before the test ran
+func (v *Validator) WithBaseline(baseline *Snapshot) *Validator {
+	v.baseline = baseline
+	return v
+}
+
+// database returns the database a query names, or the validated database for ""
+func (v *Validator) database(name string) (*DatabaseManager, error) {
+	if name == "" {
+		return v.dm, nil
+	}
+	if dm, ok := v.databases[name]; ok {
+		return dm, nil
+	}
+	return nil, fmt.Errorf("database %s is not available to the validator", name)
+}
+
+// ValidateFile loads an expected-state file and checks the database against it
+func (v *Validator) ValidateFile(ctx context.Context, path string) (*ValidationReport, error) {
+	expected, err := LoadExpectedFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := v.Validate(ctx, expected)
+	if err != nil {
+		return nil, err
+	}
+	report.File = path
+	return report, nil
+}
+
+// Validate checks the database against an expected state. Tables are read from one
+// consistent snapshot and queries run afterwards; differences are reported in the returned
+// report, and an error is only returned when the tables cannot be read.
+func (v *Validator) Validate(ctx context.Context, expected *ExpectedState) (*ValidationReport, error) {
+	ctx, cancel := v.dm.operationContext(ctx)
+	defer cancel()
+
+	if expected.Changes != nil && v.baseline == nil {
+		return nil, fmt.Errorf("the expected state has changes, which need a baseline snapshot")
+	}
+
+	schema, err := v.dm.DescribeSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, table := range expected.Tables {
+		if schema.Table(table.Table) != nil {
+			names = append(names, table.Table)
+		}
+	}
+
+	dumps := map[string]*TableDump{}
+	if len(names) > 0 {
+		read, err := v.dm.readTables(ctx, schema, names, true)
+		if err != nil {
+			return nil, err
+		}
+		for _, dump := range read {
+			dumps[dump.Table] = dump
+		}
+	}
+
+	now := time.Now()
+	report := &ValidationReport{DatabaseID: v.dm.config.DatabaseID, Passed: true}
+	for _, expectation := range expected.Tables {
+		var dump *TableDump
+		table := schema.Table(expectation.Table)
+		if table != nil {
+			dump = dumps[table.QualifiedName()]
+		}
+
+		result := validateTable(expectation, table, dump, schema.Dialect, now)
+		report.Tables = append(report.Tables, result)
+		if !result.Passed {
+			report.Passed = false
+		}
+	}
+
+	for _, query := range expected.Queries {
+		result := v.validateQuery(ctx, query, now)
+		report.Queries = append(report.Queries, result)
+		if !result.Passed {
+			report.Passed = false
+		}
+	}
+
+	if expected.Changes != nil {
+		current, err := v.dm.Snapshot(ctx)
+		if err != nil {
+			return nil, err
+		}
+		diff, err := Diff(v.baseline, current)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare with the baseline: %w", err)
+		}
+		report.Changes = checkChanges(expected.Changes, diff, now)
+		for _, result := range report.Changes {
+			if !result.Passed {
+				report.Passed = false
+			}
+		}
+	}
+	return report, nil
+}
+
+// validateTable compares one table expectation with the rows read from the database; now
+// is what !within compares timestamps with
+func validateTable(expected *TableExpectation, table *Table, dump *TableDump, dialect Dialect, now time.Time) *TableResult {
+	result := &TableResult{Table: expected.Table, ExpectedCount: expected.Count}
+	if table == nil || dump == nil {
+		result.Mismatches = append(result.Mismatches, Mismatch{Message: "table does not exist"})
+		return result
+	}
+
+	result.ActualCount = len(dump.Rows)
+	if expected.Count != nil && *expected.Count != result.ActualCount {
+		result.Mismatches = append(result.Mismatches, Mismatch{
+			Expected: *expected.Count,
+			Actual:   result.ActualCount,
+			Message:  fmt.Sprintf("expected %d rows, found %d", *expected.Count, result.ActualCount),
+		})
+	}
+
+	rows := newTableRowMatcher(table, dump, dialect, now)
+	claimed := make([]bool, len(dump.Rows))
+	if expected.Columns != nil {
+		i, mismatches := rows.matchAny(expected.Columns)
+		if i >= 0 {
+			claimed[i] = true
+		}
+		result.Mismatches = append(result.Mismatches, mismatches...)
+	}
+	if expected.Order == RowsSet {
+		result.Mismatches = append(result.Mismatches, rows.matchSet(expected.Rows, claimed)...)
+	} else {
+		result.Mismatches = append(result.Mismatches, rows.matchOrdered(expected.Rows, claimed)...)
+	}
+	for _, keyed := range expected.ByKey {
+		result.Mismatches = append(result.Mismatches, rows.matchKey(keyed, claimed)...)
+	}
+	for _, absent := range expected.Absent {
+		result.Mismatches = append(result.Mismatches, rows.matchAbsent(absent)...)
+	}
+	if expected.Exact {
+		for i := range dump.Rows {
+			if !claimed[i] {
+				result.Mismatches = append(result.Mismatches, Mismatch{Row: i + 1, Key: rows.key(i), Message: "unexpected row"})
+			}
+		}
+	}
+
+	result.Passed = len(result.Mismatches) == 0
+	return result
+}
+
+// rowMatcher compares expected rows with the rows of a table or query result
+type rowMatcher struct {
+	dump  *TableDump
+	keys  []string
+	index map[string]int
+	types map[string]columnType
+	now   time.Time
+}
+
+// newRowMatcher creates a rowMatcher for rows with the given column types; keys are the
+// primary key columns, if any
+func newRowMatcher(dump *TableDump, types map[string]columnType, keys []string, now time.Time) *rowMatcher {
+	m := &rowMatcher{dump: dump, keys: keys, types: types, now: now, index: make(map[string]int, len(dump.Columns))}
+	for i, name := range dump.Columns {
+		if _, ok := m.index[name]; !ok {
+			m.index[name] = i
+		}
+	}
+	return m
+}
+
+// newTableRowMatcher creates a rowMatcher for the rows of a table; columns of types that
+// cannot be parsed are left without a type and reported when an expectation uses them
+func newTableRowMatcher(table *Table, dump *TableDump, dialect Dialect, now time.Time) *rowMatcher {
+	types := make(map[string]columnType, len(dump.Columns))
+	for _, name := range dump.Columns {
+		if ct, err := parseColumnType(table.Column(name).Type, dialect); err == nil {
+			types[name] = ct
+		}
+	}
+
+	var keys []string
+	for _, key := range table.PrimaryKey {
+		keys = append(keys, key.Name)
+	}
+	return newRowMatcher(dump, types, keys, now)
+}
+
+// match compares row i of the table with an expected row
+func (m *rowMatcher) match(i int, expected ExpectedRow) []Mismatch {
+	var mismatches []Mismatch
+	for _, value := range expected {
+		index, ok := m.index[value.Column]
+		if !ok {
+			mismatches = append(mismatches, Mismatch{Row: i + 1, Column: value.Column, Message: "column does not exist"})
+			continue
+		}
+		ct, ok := m.types[value.Column]
+		if !ok {
+			mismatches = append(mismatches, Mismatch{Row: i + 1, Column: value.Column, Message: "column type is not supported"})
+			continue
+		}
+
+		actual := m.dump.Rows[i][index]
+		expected := value.Value
+		var equal bool
+		var err error
+		if matcher, ok := value.Value.(matcher); ok {
+			equal, err = matcher.match(actual, m.matchRow(i))
+			expected = matcher.String()
+		} else {
+			equal, err = valuesEqual(value.Value, actual, ct)
+		}
+
+		switch {
+		case err != nil:
+			mismatches = append(mismatches, Mismatch{Row: i + 1, Column: value.Column, Expected: expected, Message: "invalid expected value: " + err.Error()})
+		case !equal:
+			mismatches = append(mismatches, Mismatch{
+				Row:      i + 1,
+				Column:   value.Column,
+				Expected: expected,
+				Actual:   actual,
+				Message:  fmt.Sprintf("expected %s, got %s", formatExpected(value.Value), formatValue(actual)),
+			})
+		}
+	}
+	return mismatches
+}
+
+// matchRow gives matchers access to the other columns of row i
+func (m *rowMatcher) matchRow(i int) matchRow {
+	return matchRow{
+		column: func(name string) (interface{}, bool) {
+			index, ok := m.index[name]
+			if !ok {
+				return nil, false
+			}
+			return m.dump.Rows[i][index], true
+		},
+		now: m.now,
+	}
+}
+
+// matchAny finds a row matching the expected row and returns its index. When none
+// matches it returns -1 and the differences to the closest row.
+func (m *rowMatcher) matchAny(expected ExpectedRow) (int, []Mismatch) {
+	if len(m.dump.Rows) == 0 {
+		return -1, []Mismatch{{Message: "expected a row matching columns, but the table is empty"}}
+	}
+
+	var closest []Mismatch
+	for i := range m.dump.Rows {
+		mismatches := m.match(i, expected)
+		if len(mismatches) == 0 {
+			return i, nil
+		}
+		if closest == nil || len(mismatches) < len(closest) {
+			closest = mismatches
+		}
+	}
+	return -1, closest
+}
+
+// matchOrdered compares expected row i with row i in primary key order
+func (m *rowMatcher) matchOrdered(expected []ExpectedRow, claimed []bool) []Mismatch {
+	var mismatches []Mismatch
+	for i, row := range expected {
+		if i >= len(m.dump.Rows) {
+			mismatches = append(mismatches, Mismatch{Row: i + 1, Message: "expected row is missing"})
+			continue
+		}
+		claimed[i] = true
+		mismatches = append(mismatches, m.match(i, row)...)
+	}
+	return mismatches
+}
+
+// matchSet pairs every expected row with a different matching row, in any order. An
+// expected row left without a partner is reported with its differences to the closest row.
+func (m *rowMatcher) matchSet(expected []ExpectedRow, claimed []bool) []Mismatch {
+	candidates := make([][]int, len(expected))
+	for e, row := range expected {
+		for i := range m.dump.Rows {
+			if len(m.match(i, row)) == 0 {
+				candidates[e] = append(candidates[e], i)
+			}
+		}
+	}
+
+	// Kuhn's augmenting paths: a row taken by one expected row is handed over when that
+	// expected row can move to another candidate
+	partner := make([]int, len(m.dump.Rows))
+	for i := range partner {
+		partner[i] = -1
+	}
+	var assign func(e int, seen []bool) bool
+	assign = func(e int, seen []bool) bool {
+		for _, i := range candidates[e] {
+			if seen[i] {
+				continue
+			}
+			seen[i] = true
+			if partner[i] < 0 || assign(partner[i], seen) {
+				partner[i] = e
+				return true
+			}
+		}
+		return false
+	}
+
+	var mismatches []Mismatch
+	for e, row := range expected {
+		if assign(e, make([]bool, len(m.dump.Rows))) {
+			continue
+		}
+		_, closest := m.matchAny(row)
+		if len(closest) == 0 {
+			closest = []Mismatch{{Message: "every matching row is already matched by another expected row"}}
+		}
+		for _, mismatch := range closest {
+			mismatch.ExpectedRow = e + 1
+			mismatches = append(mismatches, mismatch)
+		}
+	}
+
+	for i, e := range partner {
+		if e >= 0 {
+			claimed[i] = true
+		}
+	}
+	return mismatches
+}
+
+// matchKey looks up the row with the primary key of a keyed row and compares it
+func (m *rowMatcher) matchKey(keyed KeyedRow, claimed []bool) []Mismatch {
+	key := formatKey(keyed.Key)
+	if len(keyed.Key) != len(m.keys) {
+		return []Mismatch{{Key: key, Message: fmt.Sprintf("key has %d values, but the primary key has %d columns", len(keyed.Key), len(m.keys))}}
+	}
+
+	for i := range m.dump.Rows {
+		found := true
+		for j, column := range m.keys {
+			equal, err := valuesEqual(keyed.Key[j], m.dump.Rows[i][m.index[column]], m.types[column])
+			if err != nil {
+				return []Mismatch{{Key: key, Column: column, Message: "invalid key value: " + err.Error()}}
+			}
+			if !equal {
+				found = false
+				break
+			}
+		}
+		if !found {
+			continue
+		}
+
+		claimed[i] = true
+		mismatches := m.match(i, keyed.Row)
+		for j := range mismatches {
+			mismatches[j].Key = key
+		}
+		return mismatches
+	}
+	return []Mismatch{{Key: key, Message: "row does not exist"}}
+}
+
+// matchAbsent reports every row that matches an entry which must not exist
+func (m *rowMatcher) matchAbsent(absent ExpectedRow) []Mismatch {
+	var mismatches []Mismatch
+	for _, value := range absent {
+		if _, ok := m.index[value.Column]; !ok {
+			mismatches = append(mismatches, Mismatch{Column: value.Column, Message: "column does not exist"})
+		}
+	}
+	if len(mismatches) > 0 {
+		return mismatches
+	}
+
+	for i := range m.dump.Rows {
+		if len(m.match(i, absent)) == 0 {
+			mismatches = append(mismatches, Mismatch{Row: i + 1, Key: m.key(i), Message: "row must not exist: " + formatRow(absent)})
+		}
+	}
+	return mismatches
+}
+
+// key formats the primary key of row i, or returns "" for rows without one
+func (m *rowMatcher) key(i int) string {
+	if len(m.keys) == 0 {
+		return ""
+	}
+	values := make([]interface{}, len(m.keys))
+	for j, column := range m.keys {
+		values[j] = m.dump.Rows[i][m.index[column]]
+	}
+	return formatKey(values)
+}
+
+// formatKey formats primary key values: a single value as is, composite keys as a list
+func formatKey(values []interface{}) string {
+	if len(values) == 1 {
+		return formatValue(values[0])
+	}
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = formatValue(value)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// formatRow formats an expected row for mismatch messages
+func formatRow(row ExpectedRow) string {
+	parts := make([]string, len(row))
+	for i, value := range row {
+		parts[i] = value.Column + ": " + formatExpected(value.Value)
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// valuesEqual reports whether an expected value equals a column value read by dumpTable.
+// Both sides are converted as fixture values for the column type, so 1000 matches a
+// NUMERIC "1000" and "2024-01-01T09:00:00+09:00" matches the same instant in UTC.
+func valuesEqual(expected, actual interface{}, ct columnType) (bool, error) {
+	if expected == nil || actual == nil {
+		return expected == nil && actual == nil, nil
+	}
+
+	if !ct.array {
+		want, err := canonicalValue(expected, ct.kind)
+		if err != nil {
+			return false, err
+		}
+		got, err := canonicalValue(actual, ct.kind)
+		if err != nil {
+			return false, err
+		}
+		return want == got, nil
+	}
+
+	wantItems, ok := expected.([]interface{})
+	if !ok {
+		return false, fmt.Errorf("expected a list, got %T", expected)
+	}
+	gotItems, ok := actual.([]interface{})
+	if !ok || len(wantItems) != len(gotItems) {
+		return false, nil
+	}
+	item := columnType{kind: ct.kind}
+	for i := range wantItems {
+		equal, err := valuesEqual(wantItems[i], gotItems[i], item)
+		if err != nil || !equal {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// canonicalValue converts a non-NULL scalar to text that is equal for equal values
+func canonicalValue(value interface{}, kind columnKind) (string, error) {
+	if f, ok := value.(float64); ok && (kind == kindNumeric || kind == kindPGNumeric) {
+		// 12.5 in YAML means the decimal 12.5, not its nearest binary fraction
+		value = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	converted, err := convertScalar(value, kind)
+	if err != nil {
+		return "", err
+	}
+
+	switch v := converted.(type) {
+	case string:
+		return v, nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case []byte:
+		return string(v), nil
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano), nil
+	case civil.Date:
+		return v.String(), nil
+	case spanner.NullNumeric:
+		return v.Numeric.RatString(), nil
+	case spanner.PGNumeric:
+		r, err := toRat(v.Numeric)
+		if err != nil {
+			return v.Numeric, nil
+		}
+		return r.RatString(), nil
+	case spanner.NullJSON:
+		return canonicalJSON(v.Value)
+	case spanner.PGJsonB:
+		return canonicalJSON(v.Value)
+	}
+	return "", fmt.Errorf("unsupported value %T", converted)
+}
+
+// canonicalJSON encodes a JSON value with sorted keys
+func canonicalJSON(value interface{}) (string, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid JSON value: %w", err)
+	}
+	return string(b), nil
+}
+
+// formatExpected formats an expected value or matcher for mismatch messages
+func formatExpected(value interface{}) string {
+	if m, ok := value.(matcher); ok {
+		return m.String()
+	}
+	return formatValue(value)
+}
+
+// formatValue formats a value for mismatch messages
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return strconv.Quote(v)
+	}
+	if b, err := json.Marshal(value); err == nil {
+		return string(b)
+	}
+	return fmt.Sprint(value)
+}
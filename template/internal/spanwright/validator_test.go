@@ -0,0 +1,166 @@
+package spanwright
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValuesEqual(t *testing.T) {
+	tests := []struct {
+		name       string
+		columnType string
+		expected   interface{}
+		actual     interface{}
+		want       bool
+		wantErr    bool
+	}{
+		{name: "string", columnType: "STRING(MAX)", expected: "a", actual: "a", want: true},
+		{name: "different string", columnType: "STRING(MAX)", expected: "a", actual: "b", want: false},
+		{name: "int from YAML", columnType: "INT64", expected: 1, actual: int64(1), want: true},
+		{name: "null", columnType: "INT64", expected: nil, actual: nil, want: true},
+		{name: "null against value", columnType: "INT64", expected: nil, actual: int64(1), want: false},
+		{name: "numeric", columnType: "NUMERIC", expected: 1000, actual: "1000", want: true},
+		{name: "decimal numeric", columnType: "NUMERIC", expected: 12.5, actual: "12.5", want: true},
+		{name: "pg numeric", columnType: "numeric", expected: 0.1, actual: "0.10", want: true},
+		{name: "timestamp in another zone", columnType: "TIMESTAMP", expected: "2024-01-01T09:00:00+09:00", actual: "2024-01-01T00:00:00Z", want: true},
+		{name: "date", columnType: "DATE", expected: "2024-02-29", actual: "2024-02-29", want: true},
+		{name: "json key order", columnType: "JSON", expected: `{"b": 1, "a": [true]}`, actual: map[string]interface{}{"a": []interface{}{true}, "b": 1.0}, want: true},
+		{name: "array", columnType: "ARRAY<INT64>", expected: []interface{}{1, nil}, actual: []interface{}{int64(1), nil}, want: true},
+		{name: "array length", columnType: "ARRAY<INT64>", expected: []interface{}{1}, actual: []interface{}{int64(1), int64(2)}, want: false},
+		{name: "invalid expected", columnType: "INT64", expected: "one", actual: int64(1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialect := DialectGoogleSQL
+			if tt.columnType == "numeric" {
+				dialect = DialectPostgreSQL
+			}
+			ct, err := parseColumnType(tt.columnType, dialect)
+			if err != nil {
+				t.Fatalf("parseColumnType() error = %v", err)
+			}
+
+			got, err := valuesEqual(tt.expected, tt.actual, ct)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("valuesEqual() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("valuesEqual() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTable(t *testing.T) {
+	table := &Table{Name: "Users", Columns: []*Column{
+		{Name: "UserID", Type: "STRING(36)"},
+		{Name: "Status", Type: "INT64"},
+	}, PrimaryKey: []KeyColumn{{Name: "UserID"}}}
+	dump := &TableDump{
+		Table:   "Users",
+		Columns: []string{"UserID", "Status"},
+		Rows: [][]interface{}{
+			{"user-001", int64(1)},
+			{"user-002", int64(2)},
+			{"user-003", int64(2)},
+		},
+	}
+
+	parse := func(t *testing.T, content string) *TableExpectation {
+		t.Helper()
+		state, err := ParseExpected([]byte(content))
+		if err != nil {
+			t.Fatalf("ParseExpected() error = %v", err)
+		}
+		return state.Tables[0]
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "columns match a later row",
+			content: "tables:\n  Users:\n    count: 3\n    columns: {UserID: user-002, Status: 2}\n",
+		},
+		{
+			name:    "count and closest row",
+			content: "tables:\n  Users:\n    count: 1\n    columns: {UserID: user-002, Status: 3}\n",
+			want:    []string{"expected 1 rows, found 3", "row 2 column Status expected 3, got 2"},
+		},
+		{
+			name:    "matcher",
+			content: "tables:\n  Users:\n    columns: {UserID: !regex '^admin-', Status: !range [1, 2]}\n",
+			want:    []string{`row 1 column UserID expected !regex ^admin-, got "user-001"`},
+		},
+		{
+			name:    "rows in key order",
+			content: "tables:\n  Users:\n    rows:\n      - {UserID: user-001}\n      - {UserID: user-003, Missing: 1}\n      - {UserID: user-004}\n",
+			want: []string{
+				`row 2 column UserID expected "user-003", got "user-002"`,
+				"row 2 column Missing column does not exist",
+				`row 3 column UserID expected "user-004", got "user-003"`,
+			},
+		},
+		{
+			name:    "set in any order",
+			content: "tables:\n  Users:\n    order: set\n    exact: true\n    rows:\n      - {Status: 2}\n      - {UserID: user-002}\n      - {UserID: !regex '^user-'}\n",
+		},
+		{
+			name:    "set without a free row",
+			content: "tables:\n  Users:\n    order: set\n    rows:\n      - {Status: 1}\n      - {Status: 1}\n      - {Status: 4}\n",
+			want: []string{
+				"expected row 2: every matching row is already matched by another expected row",
+				"expected row 3: row 1 column Status expected 4, got 1",
+			},
+		},
+		{
+			name:    "by key",
+			content: "tables:\n  Users:\n    by_key:\n      user-002: {Status: 2}\n      user-003: {Status: 1}\n      user-009: {}\n      [user-001, 1]: {}\n",
+			want: []string{
+				`key "user-003" column Status expected 1, got 2`,
+				`key "user-009" row does not exist`,
+				`key ["user-001", 1] key has 2 values, but the primary key has 1 columns`,
+			},
+		},
+		{
+			name:    "absent",
+			content: "tables:\n  Users:\n    absent:\n      - {UserID: user-009}\n      - {Status: 2}\n      - {Missing: 1}\n",
+			want: []string{
+				`key "user-002" row must not exist: {Status: 2}`,
+				`key "user-003" row must not exist: {Status: 2}`,
+				"column Missing column does not exist",
+			},
+		},
+		{
+			name:    "exact",
+			content: "tables:\n  Users:\n    exact: true\n    columns: {UserID: user-002}\n    by_key:\n      user-003: {}\n",
+			want:    []string{`key "user-001" unexpected row`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := validateTable(parse(t, tt.content), table, dump, DialectGoogleSQL, time.Now())
+
+			var got []string
+			for _, mismatch := range result.Mismatches {
+				got = append(got, mismatch.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("mismatches =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			if result.Passed != (len(tt.want) == 0) {
+				t.Errorf("Passed = %v", result.Passed)
+			}
+		})
+	}
+
+	result := validateTable(&TableExpectation{Table: "Missing"}, nil, nil, DialectGoogleSQL, time.Now())
+	if result.Passed || result.Mismatches[0].Message != "table does not exist" {
+		t.Errorf("validateTable() for a missing table = %+v", result)
+	}
+}
@@ -0,0 +1,124 @@
+package spanwright
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// DefaultWorkersFile is where the spanwright command writes the worker database mapping
+const DefaultWorkersFile = ".spanwright/workers.json"
+
+// maxDatabaseIDLength is Spanner's limit on database ID length
+const maxDatabaseIDLength = 30
+
+// workerHashLength is the number of hex digits of the hash kept when an ID is shortened
+const workerHashLength = 6
+
+// WorkerDatabaseID returns the ID of a worker's clone of a database: the ID with a -w<worker>
+// suffix, e.g. primary-db-w0. IDs that would exceed 30 characters are shortened and keep a
+// hash of the full ID, so different long IDs still get different clones.
+func WorkerDatabaseID(databaseID string, worker int) (string, error) {
+	if worker < 0 {
+		return "", fmt.Errorf("worker index must not be negative, got %d", worker)
+	}
+
+	suffix := "-w" + strconv.Itoa(worker)
+	id := databaseID + suffix
+	if len(id) > maxDatabaseIDLength {
+		sum := sha256.Sum256([]byte(databaseID))
+		hash := hex.EncodeToString(sum[:])[:workerHashLength]
+
+		keep := maxDatabaseIDLength - len(suffix) - len(hash) - 1
+		if keep < 1 {
+			return "", fmt.Errorf("worker index %d is too large for a database ID", worker)
+		}
+		prefix := strings.TrimRight(databaseID[:keep], "-_")
+		id = prefix + "-" + hash + suffix
+	}
+
+	if err := ValidateDatabaseID(id); err != nil {
+		return "", fmt.Errorf("invalid database ID %q for worker %d: %w", id, worker, err)
+	}
+	return id, nil
+}
+
+// ForWorker returns a copy of the configuration whose databases are the clones of one
+// worker. Logical names, schemas and fixture directories stay the same, so every command
+// works on the clones unchanged.
+func (c *Config) ForWorker(worker int) (*Config, error) {
+	if index, ok := c.Worker(); ok {
+		return nil, fmt.Errorf("configuration already addresses the databases of worker %d", index)
+	}
+
+	clone := *c
+	clone.worker = &worker
+	clone.Databases = make([]DatabaseSpec, len(c.Databases))
+	for i, spec := range c.Databases {
+		id, err := WorkerDatabaseID(spec.DatabaseID, worker)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", spec.Name, err)
+		}
+		spec.DatabaseID = id
+		clone.Databases[i] = spec
+	}
+	return &clone, nil
+}
+
+// Worker returns the worker whose clones the configuration addresses, if any
+func (c *Config) Worker() (int, bool) {
+	if c.worker == nil {
+		return 0, false
+	}
+	return *c.worker, true
+}
+
+// WorkerMapping lists the database IDs of each worker's clones, keyed by logical name
+type WorkerMapping struct {
+	Workers []WorkerDatabases `json:"workers"`
+}
+
+// WorkerDatabases holds the database IDs of one worker
+type WorkerDatabases struct {
+	Index     int               `json:"index"`
+	Databases map[string]string `json:"databases"`
+}
+
+// NewWorkerMapping returns the database IDs of count workers' clones
+func (c *Config) NewWorkerMapping(count int) (*WorkerMapping, error) {
+	mapping := &WorkerMapping{Workers: make([]WorkerDatabases, 0, count)}
+	for worker := 0; worker < count; worker++ {
+		workerConfig, err := c.ForWorker(worker)
+		if err != nil {
+			return nil, err
+		}
+
+		databases := WorkerDatabases{Index: worker, Databases: make(map[string]string, len(workerConfig.Databases))}
+		for _, spec := range workerConfig.Databases {
+			databases.Databases[spec.Name] = spec.DatabaseID
+		}
+		mapping.Workers = append(mapping.Workers, databases)
+	}
+	return mapping, nil
+}
+
+// WriteFile stores the mapping as JSON, creating the directory if needed
+func (m *WorkerMapping) WriteFile(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+
+	content, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode worker mapping: %w", err)
+	}
+	if err := os.WriteFile(path, append(content, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write worker mapping %s: %w", path, err)
+	}
+	return nil
+}
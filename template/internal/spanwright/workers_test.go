@@ -0,0 +1,154 @@
+package spanwright
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWorkerDatabaseID(t *testing.T) {
+	tests := []struct {
+		name       string
+		databaseID string
+		worker     int
+		want       string
+	}{
+		{name: "short ID", databaseID: "primary-db", worker: 0, want: "primary-db-w0"},
+		{name: "exactly 30 characters", databaseID: "abcdefghijklmnopqrstuvwxyz", worker: 12, want: "abcdefghijklmnopqrstuvwxyz-w12"},
+		{name: "shortened with hash", databaseID: "very-long-primary-database-id", worker: 3, want: "very-long-primary-da-4a73c7-w3"},
+		{name: "separator before hash is trimmed", databaseID: "long_database_iden_tifier_xyz", worker: 10, want: "long_database_iden-d9214b-w10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := WorkerDatabaseID(tt.databaseID, tt.worker)
+			if err != nil {
+				t.Fatalf("WorkerDatabaseID() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("WorkerDatabaseID(%q, %d) = %q, want %q", tt.databaseID, tt.worker, got, tt.want)
+			}
+			if len(got) > 30 {
+				t.Errorf("WorkerDatabaseID() = %q is %d characters long", got, len(got))
+			}
+		})
+	}
+
+	// Long IDs that only differ after the kept prefix still get distinct clones
+	a, _ := WorkerDatabaseID("shared-prefix-for-databases-a", 0)
+	b, _ := WorkerDatabaseID("shared-prefix-for-databases-b", 0)
+	if a == b {
+		t.Errorf("WorkerDatabaseID() = %q for two different IDs", a)
+	}
+
+	if _, err := WorkerDatabaseID("primary-db", -1); err == nil {
+		t.Error("WorkerDatabaseID() with a negative worker succeeded, want an error")
+	}
+}
+
+func TestConfigForWorker(t *testing.T) {
+	config := &Config{Databases: []DatabaseSpec{
+		{Name: "primary", DatabaseID: "primary-db", SchemaPath: "./schema", FixtureDir: "primary-db"},
+		{Name: "secondary", DatabaseID: "secondary-db", SchemaPath: "./schema2", FixtureDir: "secondary-db"},
+	}}
+
+	worker, err := config.ForWorker(1)
+	if err != nil {
+		t.Fatalf("ForWorker() error = %v", err)
+	}
+
+	want := []DatabaseSpec{
+		{Name: "primary", DatabaseID: "primary-db-w1", SchemaPath: "./schema", FixtureDir: "primary-db"},
+		{Name: "secondary", DatabaseID: "secondary-db-w1", SchemaPath: "./schema2", FixtureDir: "secondary-db"},
+	}
+	if !reflect.DeepEqual(worker.Databases, want) {
+		t.Errorf("ForWorker() databases = %+v, want %+v", worker.Databases, want)
+	}
+	if index, ok := worker.Worker(); !ok || index != 1 {
+		t.Errorf("Worker() = %d, %v, want 1, true", index, ok)
+	}
+	if config.Databases[0].DatabaseID != "primary-db" {
+		t.Errorf("ForWorker() changed the original configuration: %+v", config.Databases)
+	}
+	if _, err := worker.ForWorker(2); err == nil {
+		t.Error("ForWorker() on a worker configuration succeeded, want an error")
+	}
+}
+
+func TestWorkerMappingFile(t *testing.T) {
+	config := &Config{Databases: []DatabaseSpec{
+		{Name: "primary", DatabaseID: "primary-db"},
+		{Name: "secondary", DatabaseID: "secondary-db"},
+	}}
+
+	mapping, err := config.NewWorkerMapping(2)
+	if err != nil {
+		t.Fatalf("NewWorkerMapping() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), ".spanwright", "workers.json")
+	if err := mapping.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(content, &got); err != nil {
+		t.Fatalf("mapping is not JSON: %v", err)
+	}
+	want := map[string]interface{}{"workers": []interface{}{
+		map[string]interface{}{"index": 0.0, "databases": map[string]interface{}{"primary": "primary-db-w0", "secondary": "secondary-db-w0"}},
+		map[string]interface{}{"index": 1.0, "databases": map[string]interface{}{"primary": "primary-db-w1", "secondary": "secondary-db-w1"}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mapping = %s", strings.TrimSpace(string(content)))
+	}
+}
+
+func TestLoadConfigWorker(t *testing.T) {
+	dir := t.TempDir()
+	configFile := writeTestFile(t, dir, "spanwright.yaml", `
+project_id: test-project
+instance_id: test-instance
+emulator_host: localhost:9010
+databases:
+  - name: primary
+    database_id: primary-db
+    schema_path: ./schema
+  - name: secondary
+    database_id: secondary-db
+    schema_path: ./schema2
+`)
+	for _, key := range []string{"PROJECT_ID", "INSTANCE_ID", "SPANNER_EMULATOR_HOST", "DATABASES", "DB_COUNT", "WORKER_INDEX"} {
+		t.Setenv(key, "")
+	}
+
+	config, err := LoadConfigWithOptions(LoadOptions{ConfigFile: configFile, Flags: map[string]string{"WORKER_INDEX": "1"}})
+	if err != nil {
+		t.Fatalf("LoadConfigWithOptions() error = %v", err)
+	}
+
+	// validate --worker --baseline reads the snapshot snapshot save --worker wrote
+	primary, err := config.Database("primary")
+	if err != nil {
+		t.Fatalf("Database(primary) error = %v", err)
+	}
+	if got, want := primary.SnapshotPath(".spanwright/snapshots", "before"), filepath.Join(".spanwright/snapshots", "before", "primary-db-w1.yaml"); got != want {
+		t.Errorf("SnapshotPath() = %q, want %q", got, want)
+	}
+
+	// Query expectations reading another database read the worker's clone of it
+	secondary, err := config.GetDatabaseConfig("secondary")
+	if err != nil {
+		t.Fatalf("GetDatabaseConfig(secondary) error = %v", err)
+	}
+	if secondary.DatabaseID != "secondary-db-w1" {
+		t.Errorf("GetDatabaseConfig(secondary) database = %q, want secondary-db-w1", secondary.DatabaseID)
+	}
+}
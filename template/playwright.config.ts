@@ -17,8 +17,8 @@ export default defineConfig({
   forbidOnly: !!process.env.CI,
   /* Retry on CI only */
   retries: process.env.CI ? 2 : 0,
-  /* Allow parallel execution with shared database setup */
-  workers: 1, // Limit workers in CI, more locally
+  /* Each worker gets its own database clones when `make test-scenario WORKERS=n` creates them */
+  workers: Number(process.env.WORKERS) || 1,
   /* Reporter to use. See https://playwright.dev/docs/test-reporters */
   reporter: [['html'], ['list'], ['json', { outputFile: 'test-results/results.json' }]],
   /* Shared settings for all the projects below. See https://playwright.dev/docs/api/class-testoptions. */
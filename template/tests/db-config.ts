@@ -1,10 +1,45 @@
+import { existsSync, readFileSync } from 'fs';
+import path from 'path';
+
 export interface DatabaseConfig {
   primaryDbId: string;
   secondaryDbId: string;
 }
 
+// Written by `spanwright workers`: the database clones of each parallel worker
+export interface WorkerMapping {
+  workers: { index: number; databases: Record<string, string> }[];
+}
+
+export function getWorkersFile(): string {
+  return process.env.SPANWRIGHT_WORKERS_FILE || path.join(process.cwd(), '.spanwright', 'workers.json');
+}
+
+// Returns the database IDs of the current Playwright worker's clones, keyed by logical
+// name, or undefined when no clones were set up
+export function getWorkerDatabases(): Record<string, string> | undefined {
+  const index = process.env.TEST_PARALLEL_INDEX;
+  const workersFile = getWorkersFile();
+  if (index === undefined || !existsSync(workersFile)) {
+    return undefined;
+  }
+
+  const mapping: WorkerMapping = JSON.parse(readFileSync(workersFile, 'utf-8'));
+  const worker = mapping.workers.find(w => w.index === Number(index));
+  if (!worker) {
+    throw new Error(`No database clones for worker ${index} in ${workersFile}; run spanwright workers with a larger --count`);
+  }
+  return worker.databases;
+}
 
 export function getDatabaseConfig(): DatabaseConfig {
+  const workerDatabases = getWorkerDatabases();
+  if (workerDatabases) {
+    return {
+      primaryDbId: workerDatabases.primary,
+      secondaryDbId: workerDatabases.secondary
+    };
+  }
 
   if (!process.env.PRIMARY_DB_ID) {
     throw new Error('PRIMARY_DB_ID is not set');
@@ -13,13 +48,12 @@ export function getDatabaseConfig(): DatabaseConfig {
   if (!process.env.SECONDARY_DB_ID) {
     throw new Error('SECONDARY_DB_ID is not set');
   }
-  
+
   const primaryDbId = process.env.PRIMARY_DB_ID;
   const secondaryDbId = process.env.SECONDARY_DB_ID;
-  
+
   return {
     primaryDbId,
     secondaryDbId
   };
 }
-
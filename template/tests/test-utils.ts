@@ -2,6 +2,7 @@ import { execFileSync } from 'child_process';
 import { existsSync, readdirSync, statSync } from 'fs';
 import path from 'path';
 import { fileURLToPath } from 'url';
+import { getWorkerDatabases } from './db-config';
 
 /**
  * Simple test utilities
@@ -51,7 +52,28 @@ export function mockValidateDatabase(databaseId: string): ValidationResult[] {
   }));
 }
 
-export function validateDatabaseState(database: 'primary' | 'secondary', databaseId?: string): boolean {
+// Saves the current contents of the databases as a named baseline for validateDatabaseState
+export function saveSnapshot(name: string, database?: 'primary' | 'secondary'): void {
+  runSnapshotCommand('save', name, database);
+}
+
+// Puts the rows of a saved snapshot back, e.g. in beforeEach, without touching the schema
+export function restoreSnapshot(name: string, database?: 'primary' | 'secondary'): void {
+  runSnapshotCommand('restore', name, database);
+}
+
+function runSnapshotCommand(action: 'save' | 'restore', name: string, database?: 'primary' | 'secondary'): void {
+  const args = ['run', './cmd/spanwright', 'snapshot', action, name];
+  if (database) {
+    args.push('--database', database);
+  }
+  if (getWorkerDatabases()) {
+    args.push('--worker', process.env.TEST_PARALLEL_INDEX!);
+  }
+  runCommand('go', args);
+}
+
+export function validateDatabaseState(database: 'primary' | 'secondary', databaseId?: string, baseline?: string): boolean {
   const stack = new Error().stack;
   const scenarioMatch = stack?.match(/scenarios\/([^/]+)\/tests/);
   if (!scenarioMatch) {
@@ -64,10 +86,11 @@ export function validateDatabaseState(database: 'primary' | 'secondary', databas
     throw new Error(`Expected file not found: ${validationFile}`);
   }
   
+  const workerDatabases = getWorkerDatabases();
   const config  = {
     projectId: process.env.PROJECT_ID || 'test-project',
     instanceId: process.env.INSTANCE_ID || 'test-instance',
-    databaseId: databaseId || (database === 'primary' 
+    databaseId: workerDatabases?.[database] || databaseId || (database === 'primary' 
       ? process.env.PRIMARY_DB_ID || 'primary-db'
       : process.env.SECONDARY_DB_ID || 'secondary-db'),
     emulatorHost: process.env.SPANNER_EMULATOR_HOST || 'localhost:9010'
@@ -75,33 +98,115 @@ export function validateDatabaseState(database: 'primary' | 'secondary', databas
   
   const { projectId, instanceId, databaseId: targetDatabaseId, emulatorHost } = config;
   
-  const spalidateArgs = [
-    '--project', projectId,
-    '--instance', instanceId,
-    '--database', targetDatabaseId,
-    validationFile
+  const validateArgs = [
+    'run', './cmd/spanwright', 'validate',
+    '--project-id', projectId,
+    '--instance-id', instanceId,
+    '--database', database,
+    '--file', validationFile,
+    '--json'
   ];
+  // --worker points the baseline snapshot and the databases read by queries at this worker's
+  // clones too, as runSnapshotCommand does when saving the snapshot
+  if (workerDatabases) {
+    validateArgs.push('--worker', process.env.TEST_PARALLEL_INDEX!);
+  } else {
+    validateArgs.push('--database-id', targetDatabaseId);
+  }
+  if (baseline) {
+    validateArgs.push('--baseline', baseline);
+  }
   
+  let output: string;
   try {
-    execFileSync('spalidate', spalidateArgs, { 
+    output = execFileSync('go', validateArgs, { 
       encoding: 'utf-8',
       env: { ...process.env, SPANNER_EMULATOR_HOST: emulatorHost },
-      timeout: 30000,
-      maxBuffer: 1024 * 1024
+      timeout: 60000,
+      maxBuffer: 1024 * 1024,
+      stdio: ['ignore', 'pipe', 'pipe']
     });
-    
-    console.log(`✅ Database validation passed for ${database}: ${validationFile}`);
-    return true;
   } catch (error: any) {
+    if (!error.stdout) {
+      throw new Error([
+        `❌ Database validation could not run for ${database} database`,
+        `Command: go ${validateArgs.join(' ')}`,
+        error.stderr || error.message
+      ].join('\n'));
+    }
+    output = error.stdout;
+  }
+  
+  const reports: DatabaseValidationReport[] = JSON.parse(output);
+  const failures = reports.flatMap(report => [
+    ...report.tables.flatMap(table =>
+      (table.mismatches || []).map(mismatch => `  ${table.table}: ${formatMismatch(mismatch)}`)
+    ),
+    ...(report.changes || []).flatMap(table =>
+      (table.mismatches || []).map(mismatch => `  changes of ${table.table}: ${formatMismatch(mismatch)}`)
+    ),
+    ...(report.queries || []).flatMap(query =>
+      (query.mismatches || []).map(mismatch => `  query ${query.query}: ${formatMismatch(mismatch)}`)
+    )
+  ]);
+  
+  if (failures.length > 0) {
     const errorDetails = [
       `❌ Database validation failed for ${database} database`,
       `Validation file: ${validationFile}`,
-      `Command: spalidate ${spalidateArgs.join(' ')}`,
       `Database ID: ${targetDatabaseId}`,
       `Emulator: ${emulatorHost}`,
-       error.stdout
+      ...failures
     ];
     
     throw new Error(errorDetails.join('\n'));
   }
-}
\ No newline at end of file
+  
+  console.log(`✅ Database validation passed for ${database}: ${validationFile}`);
+  return true;
+}
+
+// Structured report printed by `spanwright validate --json`
+export interface ValidationMismatch {
+  row?: number;
+  key?: string;
+  expected_row?: number;
+  column?: string;
+  expected?: unknown;
+  actual?: unknown;
+  message: string;
+}
+
+export interface TableValidationResult {
+  table: string;
+  passed: boolean;
+  expected_count?: number;
+  actual_count: number;
+  mismatches?: ValidationMismatch[];
+}
+
+export interface QueryValidationResult {
+  query: string;
+  database?: string;
+  passed: boolean;
+  row_count: number;
+  mismatches?: ValidationMismatch[];
+}
+
+export interface DatabaseValidationReport {
+  database_id: string;
+  file?: string;
+  passed: boolean;
+  tables: TableValidationResult[];
+  changes?: TableValidationResult[];
+  queries?: QueryValidationResult[];
+}
+
+function formatMismatch(mismatch: ValidationMismatch): string {
+  const location = [
+    mismatch.expected_row ? `expected row ${mismatch.expected_row}:` : '',
+    mismatch.key ? `key ${mismatch.key}` : (mismatch.row ? `row ${mismatch.row}` : ''),
+    mismatch.column ? `column ${mismatch.column}` : ''
+  ].filter(Boolean).join(' ');
+  return location ? `${location} ${mismatch.message}` : mismatch.message;
+}